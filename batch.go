@@ -0,0 +1,139 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"unicode/utf8"
+)
+
+// Примерная стоимость служебной части одной строки в промпте ({"id": ..., "text": ...})
+const batchItemOverheadTokens = 12
+
+// batchingTranslator режет большой проект на пачки по количеству строк и
+// оценке токенов, чтобы ответ модели не обрезался по лимиту вывода.
+type batchingTranslator struct {
+	next        Translator
+	maxItems    int
+	maxTokens   int
+	concurrency int
+}
+
+func newBatchingTranslator(next Translator, config Config) *batchingTranslator {
+	return &batchingTranslator{
+		next:        next,
+		maxItems:    max(config.BatchMaxItems, 1),
+		maxTokens:   max(config.BatchMaxTokens, 1),
+		concurrency: max(config.BatchWorkers, 1),
+	}
+}
+
+// estimateTokens грубо оценивает размер строки в токенах (~4 символа на токен).
+func estimateTokens(text string) int {
+	return utf8.RuneCountInString(text)/4 + batchItemOverheadTokens
+}
+
+// splitBatches делит строки на пачки, не превышающие maxItems и maxTokens.
+// Одна строка больше maxTokens уходит отдельной пачкой.
+func splitBatches(items []TranslationItem, maxItems, maxTokens int) [][]TranslationItem {
+	var batches [][]TranslationItem
+	var current []TranslationItem
+	currentTokens := 0
+
+	for _, item := range items {
+		tokens := estimateTokens(item.Original)
+		if len(current) > 0 && (len(current) >= maxItems || currentTokens+tokens > maxTokens) {
+			batches = append(batches, current)
+			current = nil
+			currentTokens = 0
+		}
+		current = append(current, item)
+		currentTokens += tokens
+	}
+	if len(current) > 0 {
+		batches = append(batches, current)
+	}
+	return batches
+}
+
+// Translate переводит пачки параллельно. Пачка, которая не перевелась и после
+// повторов, не отменяет остальные: ее строки просто отсутствуют в ответе, и
+// translateAndReconcile дозапросит их как недостающие. Ошибка возвращается,
+// только если не перевелась ни одна пачка.
+func (t *batchingTranslator) Translate(ctx context.Context, items []TranslationItem) ([]TranslationItem, error) {
+	batches := splitBatches(items, t.maxItems, t.maxTokens)
+	if len(batches) <= 1 {
+		// Одна пачка могла поделиться из-за MAX_TOKENS: переведенные половины
+		// возвращаем так же, как переведенные пачки
+		translated, err := t.translateBatch(ctx, items)
+		if err == nil || len(translated) == 0 {
+			return translated, err
+		}
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		slog.WarnContext(ctx, "⚠️ Часть строк не переведена, они будут дозапрошены",
+			"items", len(items), "translated", len(translated), "error", err)
+		return translated, nil
+	}
+
+	slog.InfoContext(ctx, "📦 Перевод пачками", "items", len(items), "batches", len(batches), "concurrency", t.concurrency)
+
+	results := make([][]TranslationItem, len(batches))
+	errs := make([]error, len(batches))
+
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, t.concurrency)
+
+	for i, batch := range batches {
+		wg.Add(1)
+		sem <- struct{}{}
+
+		go func(i int, batch []TranslationItem) {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			translated, err := t.translateBatch(ctx, batch)
+			results[i] = translated
+			if err != nil {
+				errs[i] = fmt.Errorf("batch %d/%d: %w", i+1, len(batches), err)
+				slog.WarnContext(ctx, "⚠️ Пачка не переведена, строки будут дозапрошены", "batch", i+1, "of", len(batches),
+					"items", len(batch), "translated", len(translated), "error", err)
+				return
+			}
+			slog.InfoContext(ctx, "📦 Пачка переведена", "batch", i+1, "of", len(batches), "items", len(translated))
+		}(i, batch)
+	}
+	wg.Wait()
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	var merged []TranslationItem
+	for i := range batches {
+		merged = append(merged, results[i]...)
+	}
+	if len(merged) == 0 {
+		return nil, errors.Join(errs...)
+	}
+	return merged, nil
+}
+
+// translateBatch переводит одну пачку. Если ответ обрезан по лимиту токенов
+// (MAX_TOKENS), пачка делится пополам, пока не останется одна строка.
+// Возвращает и то, что удалось перевести, и ошибку непереведенной половины.
+func (t *batchingTranslator) translateBatch(ctx context.Context, batch []TranslationItem) ([]TranslationItem, error) {
+	translated, err := t.next.Translate(ctx, batch)
+	var fe *geminiFinishError
+	if err == nil || len(batch) < 2 || !errors.As(err, &fe) || fe.Reason != "MAX_TOKENS" {
+		return translated, err
+	}
+
+	half := len(batch) / 2
+	slog.WarnContext(ctx, "✂️ Ответ обрезан по лимиту токенов, делим пачку", "items", len(batch), "halves", []int{half, len(batch) - half})
+	first, errFirst := t.translateBatch(ctx, batch[:half])
+	second, errSecond := t.translateBatch(ctx, batch[half:])
+	return append(first, second...), errors.Join(errFirst, errSecond)
+}
@@ -0,0 +1,187 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func testItems(n int, text string) []TranslationItem {
+	items := make([]TranslationItem, n)
+	for i := range items {
+		items[i] = TranslationItem{ID: strconv.Itoa(i + 1), Original: text}
+	}
+	return items
+}
+
+func batchSizes(batches [][]TranslationItem) []int {
+	sizes := make([]int, len(batches))
+	for i, batch := range batches {
+		sizes[i] = len(batch)
+	}
+	return sizes
+}
+
+func TestSplitBatches(t *testing.T) {
+	short := "Hello"                    // 5/4 + 12 = 13 токенов
+	long := strings.Repeat("word ", 80) // 400/4 + 12 = 112 токенов
+
+	tests := []struct {
+		name      string
+		items     []TranslationItem
+		maxItems  int
+		maxTokens int
+		want      []int
+	}{
+		{"empty", nil, 10, 1000, []int{}},
+		{"fits in one", testItems(5, short), 10, 1000, []int{5}},
+		{"by item count", testItems(7, short), 3, 1000, []int{3, 3, 1}},
+		{"by tokens", testItems(5, short), 100, 30, []int{2, 2, 1}},
+		{"oversized item goes alone", testItems(3, long), 100, 50, []int{1, 1, 1}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := batchSizes(splitBatches(tt.items, tt.maxItems, tt.maxTokens))
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("batch sizes = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// fakeTranslator переводит строку как "pl:<оригинал>" и падает на пачках,
+// для которых fail вернул ошибку.
+type fakeTranslator struct {
+	mu    sync.Mutex
+	calls [][]string
+	fail  func(batch []TranslationItem) error
+}
+
+func (f *fakeTranslator) Translate(ctx context.Context, items []TranslationItem) ([]TranslationItem, error) {
+	var ids []string
+	for _, item := range items {
+		ids = append(ids, item.ID)
+	}
+	f.mu.Lock()
+	f.calls = append(f.calls, ids)
+	f.mu.Unlock()
+
+	if f.fail != nil {
+		if err := f.fail(items); err != nil {
+			return nil, err
+		}
+	}
+	results := make([]TranslationItem, len(items))
+	for i, item := range items {
+		results[i] = TranslationItem{ID: item.ID, Translation: "pl:" + item.Original}
+	}
+	return results, nil
+}
+
+func translatedIDs(items []TranslationItem) []string {
+	ids := make([]string, len(items))
+	for i, item := range items {
+		ids[i] = item.ID
+	}
+	return ids
+}
+
+func TestBatchingTranslatorKeepsSuccessfulBatches(t *testing.T) {
+	next := &fakeTranslator{fail: func(batch []TranslationItem) error {
+		if batch[0].ID == "3" {
+			return errors.New("giving up after 5 retries")
+		}
+		return nil
+	}}
+	translator := &batchingTranslator{next: next, maxItems: 2, maxTokens: 1000, concurrency: 2}
+
+	got, err := translator.Translate(context.Background(), testItems(5, "Hello"))
+	if err != nil {
+		t.Fatalf("Translate: %v", err)
+	}
+	// Пачка 3-4 не перевелась: ее строк нет в ответе, их дозапросит translateAndReconcile
+	if want := []string{"1", "2", "5"}; !slices.Equal(translatedIDs(got), want) {
+		t.Errorf("translated ids = %v, want %v", translatedIDs(got), want)
+	}
+}
+
+func TestBatchingTranslatorAllBatchesFail(t *testing.T) {
+	next := &fakeTranslator{fail: func([]TranslationItem) error { return errors.New("down") }}
+	translator := &batchingTranslator{next: next, maxItems: 2, maxTokens: 1000, concurrency: 1}
+
+	if _, err := translator.Translate(context.Background(), testItems(4, "Hello")); err == nil {
+		t.Fatal("want an error when no batch was translated")
+	}
+}
+
+func TestBatchingTranslatorSplitsTruncatedBatch(t *testing.T) {
+	// Модель обрезает ответ на пачках больше двух строк
+	next := &fakeTranslator{fail: func(batch []TranslationItem) error {
+		if len(batch) > 2 {
+			return &geminiFinishError{Reason: "MAX_TOKENS"}
+		}
+		return nil
+	}}
+	translator := &batchingTranslator{next: next, maxItems: 100, maxTokens: 100000, concurrency: 1}
+
+	got, err := translator.Translate(context.Background(), testItems(6, "Hello"))
+	if err != nil {
+		t.Fatalf("Translate: %v", err)
+	}
+	if want := []string{"1", "2", "3", "4", "5", "6"}; !slices.Equal(translatedIDs(got), want) {
+		t.Errorf("translated ids = %v, want %v", translatedIDs(got), want)
+	}
+	// 6 -> 3 + 3 -> (1 + 2) + (1 + 2)
+	if len(next.calls) != 7 {
+		t.Errorf("calls = %v, want 7 requests", next.calls)
+	}
+}
+
+func TestBatchingTranslatorDoesNotSplitOtherErrors(t *testing.T) {
+	next := &fakeTranslator{fail: func([]TranslationItem) error {
+		return &geminiFinishError{Reason: "SAFETY"}
+	}}
+	translator := &batchingTranslator{next: next, maxItems: 100, maxTokens: 100000, concurrency: 1}
+
+	if _, err := translator.Translate(context.Background(), testItems(4, "Hello")); err == nil {
+		t.Fatal("want the SAFETY error")
+	}
+	if len(next.calls) != 1 {
+		t.Errorf("calls = %v, want a single request", next.calls)
+	}
+}
+
+// Строка 4 не помещается в лимит вывода ни в какой пачке. Переведенное
+// возвращается без ошибки и в одной пачке, и в нескольких, а строка 4
+// остается пробелом, который translateAndReconcile дозапросит.
+func TestBatchingTranslatorKeepsPartialBatch(t *testing.T) {
+	for _, maxItems := range []int{100, 2} {
+		t.Run(fmt.Sprintf("max %d items", maxItems), func(t *testing.T) {
+			next := &fakeTranslator{fail: func(batch []TranslationItem) error {
+				if slices.ContainsFunc(batch, func(item TranslationItem) bool { return item.ID == "4" }) {
+					return &geminiFinishError{Reason: "MAX_TOKENS"}
+				}
+				return nil
+			}}
+			translator := &batchingTranslator{next: next, maxItems: maxItems, maxTokens: 100000, concurrency: 1}
+
+			got, err := translator.Translate(context.Background(), testItems(4, "Hello"))
+			if err != nil {
+				t.Fatalf("Translate: %v", err)
+			}
+			if want := []string{"1", "2", "3"}; !slices.Equal(translatedIDs(got), want) {
+				t.Errorf("translated ids = %v, want %v", translatedIDs(got), want)
+			}
+
+			results, gapIDs, err := translateAndReconcile(context.Background(), translator, testItems(4, "Hello"), Config{})
+			if err != nil || len(results) != 3 || !slices.Equal(gapIDs, []string{"4"}) {
+				t.Errorf("translateAndReconcile = %d rows, gap %v, err %v; want 3 rows and gap [4]", len(results), gapIDs, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,104 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// DeepL принимает не больше 50 строк text[] в одном запросе
+const deeplMaxTexts = 50
+
+// Структуры для DeepL REST API /v2/translate
+type DeepLResponse struct {
+	Translations []struct {
+		DetectedSourceLanguage string `json:"detected_source_language"`
+		Text                   string `json:"text"`
+	} `json:"translations"`
+}
+
+// deeplTranslator — машинный перевод (не LLM) по протоколу DeepL.
+// Дешево и детерминированно, подходит для массовых коротких строк.
+type deeplTranslator struct {
+	config Config
+}
+
+func (t *deeplTranslator) Translate(ctx context.Context, items []TranslationItem) ([]TranslationItem, error) {
+	return translateWithDeepL(ctx, items, t.config)
+}
+
+func translateWithDeepL(ctx context.Context, items []TranslationItem, config Config) ([]TranslationItem, error) {
+	// DeepL требует source_lang вместе с glossary_id: без него глоссарий не применить
+	if config.GlossaryID != "" && strings.TrimSpace(config.DeepLSourceLang) == "" {
+		return nil, fmt.Errorf("deepl glossary %s requires DEEPL_SOURCE_LANG", config.GlossaryID)
+	}
+	slog.InfoContext(ctx, "⏳ Запрос к DeepL...", "url", config.DeepLURL, "target_lang", config.DeepLTargetLang, "count", len(items))
+
+	var results []TranslationItem
+	for start := 0; start < len(items); start += deeplMaxTexts {
+		end := min(start+deeplMaxTexts, len(items))
+		chunk := items[start:end]
+
+		texts, err := requestDeepL(ctx, chunk, config)
+		if err != nil {
+			return nil, err
+		}
+		if len(texts) != len(chunk) {
+			return nil, fmt.Errorf("deepl returned %d translations for %d texts", len(texts), len(chunk))
+		}
+
+		// DeepL возвращает переводы в том же порядке, что и text[]
+		for i, item := range chunk {
+			results = append(results, TranslationItem{
+				ID:          item.ID,
+				Original:    item.Original,
+				Translation: texts[i],
+			})
+		}
+	}
+	return results, nil
+}
+
+func requestDeepL(ctx context.Context, items []TranslationItem, config Config) ([]string, error) {
+	form := url.Values{}
+	for _, item := range items {
+		form.Add("text", item.Original)
+	}
+	form.Set("target_lang", config.DeepLTargetLang)
+	if config.DeepLSourceLang != "" {
+		form.Set("source_lang", config.DeepLSourceLang)
+	}
+	if config.GlossaryID != "" {
+		form.Set("glossary_id", config.GlossaryID)
+	}
+
+	endpoint := strings.TrimRight(config.DeepLURL, "/") + "/v2/translate"
+	encoded := form.Encode()
+	body, err := doWithRetry(ctx, config, func() (*http.Request, error) {
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, strings.NewReader(encoded))
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		req.Header.Set("Authorization", "DeepL-Auth-Key "+config.DeepLAPIKey)
+		return req, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var deeplResp DeepLResponse
+	if err := json.Unmarshal(body, &deeplResp); err != nil {
+		return nil, fmt.Errorf("invalid response format: %s", string(body))
+	}
+
+	texts := make([]string, 0, len(deeplResp.Translations))
+	for _, t := range deeplResp.Translations {
+		texts = append(texts, t.Text)
+	}
+	return texts, nil
+}
@@ -0,0 +1,129 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// deeplStub отвечает на /v2/translate переводами "pl:<текст>" в порядке text[]
+// и запоминает параметры последнего запроса.
+func deeplStub(t *testing.T, drop int) (*httptest.Server, *http.Request) {
+	var last http.Request
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v2/translate" {
+			http.NotFound(w, r)
+			return
+		}
+		if err := r.ParseForm(); err != nil {
+			t.Errorf("parse form: %v", err)
+		}
+		last = *r
+		texts := r.PostForm["text"]
+		var translations []string
+		for _, text := range texts[:len(texts)-drop] {
+			translations = append(translations, fmt.Sprintf(`{"detected_source_language": "EN", "text": %q}`, "pl:"+text))
+		}
+		fmt.Fprintf(w, `{"translations": [%s]}`, strings.Join(translations, ","))
+	}))
+	t.Cleanup(server.Close)
+	return server, &last
+}
+
+func TestTranslateWithDeepL(t *testing.T) {
+	tests := []struct {
+		name       string
+		sourceLang string
+		glossary   string
+	}{
+		{"without glossary", "EN", ""},
+		{"with glossary", "EN", "def3a26b-3e84-45b3-84ae-0c0aaf3525f7"},
+		{"auto-detected source", "", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server, last := deeplStub(t, 0)
+			config := Config{
+				DeepLURL:        server.URL + "/",
+				DeepLAPIKey:     "test-key:fx",
+				DeepLSourceLang: tt.sourceLang,
+				DeepLTargetLang: "PL",
+				GlossaryID:      tt.glossary,
+			}
+			items := []TranslationItem{{ID: "7", Original: "Save"}, {ID: "3", Original: "Cancel"}, {ID: "5", Original: "Delete"}}
+
+			got, err := translateWithDeepL(context.Background(), items, config)
+			if err != nil {
+				t.Fatalf("translateWithDeepL: %v", err)
+			}
+			var pairs []string
+			for _, item := range got {
+				pairs = append(pairs, item.ID+"="+item.Translation)
+			}
+			if want := []string{"7=pl:Save", "3=pl:Cancel", "5=pl:Delete"}; !slices.Equal(pairs, want) {
+				t.Errorf("results = %v, want %v", pairs, want)
+			}
+
+			if auth := last.Header.Get("Authorization"); auth != "DeepL-Auth-Key test-key:fx" {
+				t.Errorf("Authorization = %q", auth)
+			}
+			form := last.PostForm
+			if form.Get("target_lang") != "PL" || form.Get("source_lang") != tt.sourceLang || form.Get("glossary_id") != tt.glossary {
+				t.Errorf("form = %v", form)
+			}
+			if _, ok := form["source_lang"]; ok != (tt.sourceLang != "") {
+				t.Errorf("source_lang sent = %v, want %v", ok, tt.sourceLang != "")
+			}
+		})
+	}
+}
+
+func TestTranslateWithDeepLChunks(t *testing.T) {
+	var sizes []int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		texts := r.PostForm["text"]
+		sizes = append(sizes, len(texts))
+		var translations []string
+		for _, text := range texts {
+			translations = append(translations, fmt.Sprintf(`{"text": %q}`, text))
+		}
+		fmt.Fprintf(w, `{"translations": [%s]}`, strings.Join(translations, ","))
+	}))
+	defer server.Close()
+
+	got, err := translateWithDeepL(context.Background(), testItems(deeplMaxTexts+1, "Hello"), Config{DeepLURL: server.URL, DeepLTargetLang: "PL"})
+	if err != nil {
+		t.Fatalf("translateWithDeepL: %v", err)
+	}
+	if len(got) != deeplMaxTexts+1 || !slices.Equal(sizes, []int{deeplMaxTexts, 1}) {
+		t.Errorf("got %d results in requests of %v", len(got), sizes)
+	}
+}
+
+func TestTranslateWithDeepLCountMismatch(t *testing.T) {
+	server, _ := deeplStub(t, 1)
+	config := Config{DeepLURL: server.URL, DeepLTargetLang: "PL"}
+
+	_, err := translateWithDeepL(context.Background(), testItems(3, "Hello"), config)
+	if err == nil || !strings.Contains(err.Error(), "2 translations for 3 texts") {
+		t.Errorf("err = %v, want a count mismatch", err)
+	}
+}
+
+func TestTranslateWithDeepLGlossaryNeedsSourceLang(t *testing.T) {
+	server, last := deeplStub(t, 0)
+	config := Config{DeepLURL: server.URL, DeepLTargetLang: "PL", GlossaryID: "def3a26b"}
+
+	_, err := translateWithDeepL(context.Background(), testItems(1, "Hello"), config)
+	if err == nil || !strings.Contains(err.Error(), "DEEPL_SOURCE_LANG") {
+		t.Errorf("err = %v, want a missing source language error", err)
+	}
+	if last.Method != "" {
+		t.Error("request was sent without source_lang")
+	}
+}
@@ -0,0 +1,143 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// logRing — кольцевой буфер строк лога.
+type logRing struct {
+	mu    sync.Mutex
+	lines []string
+	next  int
+	full  bool
+}
+
+func newLogRing(capacity int) *logRing {
+	return &logRing{lines: make([]string, capacity)}
+}
+
+func (r *logRing) Write(p []byte) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, line := range bytes.Split(bytes.TrimRight(p, "\n"), []byte("\n")) {
+		r.lines[r.next] = string(line)
+		r.next = (r.next + 1) % len(r.lines)
+		if r.next == 0 {
+			r.full = true
+		}
+	}
+	return len(p), nil
+}
+
+// Last возвращает до n последних строк в порядке записи.
+func (r *logRing) Last(n int) []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var ordered []string
+	if r.full {
+		ordered = append(ordered, r.lines[r.next:]...)
+	}
+	ordered = append(ordered, r.lines[:r.next]...)
+	n = min(max(n, 0), len(ordered))
+	return ordered[len(ordered)-n:]
+}
+
+type projectLogKey struct{}
+
+// withProjectLog заводит проекту буфер последних lines строк лога. Записи с этим
+// ctx (slog.InfoContext и т.п.) попадают и в общий лог, и в буфер проекта, поэтому
+// в диагностику не попадают строки соседних воркеров.
+func withProjectLog(ctx context.Context, lines int) (context.Context, *logRing) {
+	ring := newLogRing(max(lines, 1))
+	return context.WithValue(ctx, projectLogKey{}, slog.NewTextHandler(ring, logOptions)), ring
+}
+
+// projectLogHandler дублирует запись в буфер проекта, если он есть в ctx.
+type projectLogHandler struct {
+	slog.Handler
+}
+
+func (h projectLogHandler) Handle(ctx context.Context, r slog.Record) error {
+	if project, ok := ctx.Value(projectLogKey{}).(slog.Handler); ok {
+		_ = project.Handle(ctx, r.Clone())
+	}
+	return h.Handler.Handle(ctx, r)
+}
+
+func (h projectLogHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return projectLogHandler{h.Handler.WithAttrs(attrs)}
+}
+
+func (h projectLogHandler) WithGroup(name string) slog.Handler {
+	return projectLogHandler{h.Handler.WithGroup(name)}
+}
+
+// diagnosable — редактор, который умеет сохранить снимок страницы для разбора сбоя.
+type diagnosable interface {
+	Diagnose(dir string) error
+}
+
+// Файлы диагностики в папке проекта
+const (
+	diagScreenshotFile = "screenshot.png"
+	diagPageFile       = "page.html"
+	diagTraceFile      = "trace.zip"
+	diagLogFile        = "log-tail.txt"
+)
+
+// diagnosticsDir — папка для диагностики сбоя: logs/YYYY-MM-DD/<hash>-HH-MM-SS.
+func diagnosticsDir(projectURL string) string {
+	now := time.Now()
+	return filepath.Join("logs", now.Format("2006-01-02"), projectHash(projectURL)+"-"+now.Format("15-04-05"))
+}
+
+// saveDiagnostics собирает все, что поможет разобрать сбой: последние строки лога
+// проекта и, для браузерного редактора, снимок экрана, HTML страницы и трейс Playwright.
+// Возвращает папку или "", если сохранить ничего не удалось.
+func saveDiagnostics(editor Editor, projectURL string, logTail *logRing, config Config) string {
+	dir := diagnosticsDir(projectURL)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		slog.Warn("⚠️ Не удалось создать папку диагностики", "dir", dir, "error", err)
+		return ""
+	}
+
+	tail := strings.Join(logTail.Last(config.DiagLogLines), "\n") + "\n"
+	if err := os.WriteFile(filepath.Join(dir, diagLogFile), []byte(tail), 0644); err != nil {
+		slog.Warn("⚠️ Не удалось сохранить лог", "dir", dir, "error", err)
+	}
+
+	if d, ok := editor.(diagnosable); ok {
+		if err := d.Diagnose(dir); err != nil {
+			slog.Warn("⚠️ Диагностика сохранена не полностью", "url", projectURL, "error", err)
+		}
+	}
+	slog.Info("🩺 Диагностика сохранена", "url", projectURL, "dir", dir)
+	return dir
+}
+
+// diagnosticsError — ошибка проекта с папкой собранной диагностики.
+type diagnosticsError struct {
+	Err error
+	Dir string
+}
+
+func (e *diagnosticsError) Error() string { return e.Err.Error() }
+
+func (e *diagnosticsError) Unwrap() error { return e.Err }
+
+// screenshot возвращает путь к снимку экрана, если он был сохранен.
+func (e *diagnosticsError) screenshot() string {
+	path := filepath.Join(e.Dir, diagScreenshotFile)
+	if _, err := os.Stat(path); err != nil {
+		return ""
+	}
+	return path
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"context"
+	"log/slog"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestLogRingLast(t *testing.T) {
+	tests := []struct {
+		name     string
+		capacity int
+		writes   []string
+		n        int
+		want     []string
+	}{
+		{"empty", 3, nil, 5, []string{}},
+		{"not full", 3, []string{"a\n", "b\n"}, 5, []string{"a", "b"}},
+		{"wrapped", 3, []string{"a\n", "b\n", "c\n", "d\n"}, 3, []string{"b", "c", "d"}},
+		{"fewer than stored", 3, []string{"a\n", "b\n", "c\n", "d\n"}, 2, []string{"c", "d"}},
+		{"multi-line write", 3, []string{"a\nb\n", "c\n"}, 3, []string{"a", "b", "c"}},
+		{"zero", 3, []string{"a\n"}, 0, []string{}},
+		{"negative", 3, []string{"a\n", "b\n"}, -1, []string{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ring := newLogRing(tt.capacity)
+			for _, w := range tt.writes {
+				ring.Write([]byte(w))
+			}
+			if got := ring.Last(tt.n); !slices.Equal(got, tt.want) {
+				t.Errorf("Last(%d) = %q, want %q", tt.n, got, tt.want)
+			}
+		})
+	}
+}
+
+// Строки проекта попадают в его буфер, но не в буфер соседнего воркера.
+func TestProjectLogHandler(t *testing.T) {
+	var main strings.Builder
+	logger := slog.New(projectLogHandler{slog.NewTextHandler(&main, nil)})
+
+	ctxA, ringA := withProjectLog(context.Background(), 10)
+	ctxB, ringB := withProjectLog(context.Background(), -1)
+	logger.InfoContext(ctxA, "project A")
+	logger.With("worker", 2).InfoContext(ctxB, "project B")
+	logger.Info("shared")
+
+	if a := ringA.Last(10); len(a) != 1 || !strings.Contains(a[0], "project A") {
+		t.Errorf("ring A = %q", a)
+	}
+	if b := ringB.Last(10); len(b) != 1 || !strings.Contains(b[0], "project B") {
+		t.Errorf("ring B = %q", b)
+	}
+	if lines := strings.Count(main.String(), "\n"); lines != 3 {
+		t.Errorf("main log has %d lines, want 3:\n%s", lines, main.String())
+	}
+}
@@ -0,0 +1,238 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/playwright-community/playwright-go"
+)
+
+const (
+	editorModeBrowser = "browser"
+	editorModeAPI     = "api"
+)
+
+// Editor — доступ к строкам одного проекта: сбор пустых и запись переводов.
+// Есть две реализации: через UI редактора (Playwright) и через Lokalise REST API.
+type Editor interface {
+	// Open открывает проект и возвращает его имя для логов и уведомлений.
+	Open(ctx context.Context, projectURL string) (string, error)
+	Collect(ctx context.Context) ([]TranslationItem, error)
+	// Fill записывает переводы и отмечает каждую сохраненную строку в journal.
+	// При отмене ctx начатая строка дописывается и сохраняется, следующие — нет.
+	// Ошибка отдельной строки не прерывает вставку: такие строки возвращаются в fillResult.
+	Fill(ctx context.Context, items []TranslationItem, journal fillJournal) (fillResult, error)
+	Close()
+}
+
+// fillJournal — журнал вставки одного проекта: какие строки уже начинали вставлять и какие сохранены.
+type fillJournal interface {
+	// Attempted — строку уже начинали вставлять, в этом или прошлом запуске
+	Attempted(item TranslationItem) bool
+	MarkAttempted(item TranslationItem)
+	MarkSaved(item TranslationItem)
+}
+
+// fillResult — итог вставки: сколько строк сохранено и какие не удалось вставить
+// или сохранились не так, как задумано. Такие строки не отмечаются сохраненными.
+// Skipped — ячейки, которые к моменту вставки уже заполнил кто-то другой: их не трогаем.
+type fillResult struct {
+	Total      int
+	Inserted   int
+	Skipped    []TranslationItem
+	Failed     []rowFailure
+	Mismatched []rowMismatch
+}
+
+// rowFailure — строку не удалось вставить и после повторов.
+type rowFailure struct {
+	Item TranslationItem
+	Err  string
+}
+
+// incompleteFillError — вставлены не все строки: "N of M inserted" и ключи пропущенных.
+// Err — причина, по которой вставка прервалась до конца списка.
+type incompleteFillError struct {
+	Result fillResult
+	Err    error
+}
+
+func (e *incompleteFillError) Error() string {
+	msg := fmt.Sprintf("%d of %d rows inserted", e.Result.Inserted, e.Result.Total)
+	if e.Err != nil {
+		msg = fmt.Sprintf("fill aborted, %s: %v", msg, e.Err)
+	}
+	if len(e.Result.Failed) > 0 {
+		msg += "; failed: " + strings.Join(e.failedKeys(), ", ")
+	}
+	if len(e.Result.Mismatched) > 0 {
+		msg += "; saved with unexpected text: " + strings.Join(e.mismatchedKeys(), ", ")
+	}
+	return msg
+}
+
+func (e *incompleteFillError) failedKeys() []string {
+	keys := make([]string, 0, len(e.Result.Failed))
+	for _, f := range e.Result.Failed {
+		keys = append(keys, f.Item.key())
+	}
+	return keys
+}
+
+func (e *incompleteFillError) Unwrap() error { return e.Err }
+
+func (e *incompleteFillError) mismatchedKeys() []string {
+	keys := make([]string, 0, len(e.Result.Mismatched))
+	for _, m := range e.Result.Mismatched {
+		keys = append(keys, m.Item.key())
+	}
+	return keys
+}
+
+// newEditor выбирает реализацию по config.EditorMode (переменная EDITOR_MODE).
+func newEditor(browser playwright.Browser, config Config) (Editor, error) {
+	switch strings.ToLower(strings.TrimSpace(config.EditorMode)) {
+	case "", editorModeBrowser:
+		return &browserEditor{browser: browser, config: config}, nil
+	case editorModeAPI:
+		return &apiEditor{config: config}, nil
+	default:
+		return nil, fmt.Errorf("unknown editor mode %q", config.EditorMode)
+	}
+}
+
+// browserEditor работает с проектом через UI редактора Lokalise.
+type browserEditor struct {
+	browser    playwright.Browser
+	config     Config
+	browserCtx playwright.BrowserContext
+	page       playwright.Page
+	filename   string
+	closeOnce  sync.Once // Close вызывает и watchdog, и processProject
+}
+
+func (e *browserEditor) Open(ctx context.Context, projectURL string) (string, error) {
+	// Создаем контекст с сохраненными куками
+	browserCtx, err := e.browser.NewContext(playwright.BrowserNewContextOptions{
+		StorageStatePath: playwright.String(e.config.AuthStateFile),
+	})
+	if err != nil {
+		return "", fmt.Errorf("could not create context: %v", err)
+	}
+	e.browserCtx = browserCtx
+
+	// Трейс пишется весь проект, а сохраняется только при сбое (см. Diagnose).
+	// Со снимками DOM он растет со временем вставки, поэтому включается только через TRACE
+	if e.config.Trace {
+		err = browserCtx.Tracing().Start(playwright.TracingStartOptions{
+			Screenshots: playwright.Bool(true),
+			Snapshots:   playwright.Bool(true),
+		})
+		if err != nil {
+			slog.WarnContext(ctx, "⚠️ Не удалось включить трейс", "error", err)
+		}
+	}
+
+	page, err := browserCtx.NewPage()
+	if err != nil {
+		return "", fmt.Errorf("could not create page: %v", err)
+	}
+	e.page = page
+
+	if _, err = page.Goto(projectURL); err != nil {
+		return "", fmt.Errorf("could not goto url: %v", err)
+	}
+	if isSignInURL(page.URL()) {
+		return "", errSessionExpired
+	}
+
+	sel := e.config.Selectors
+	// Ждем таблицу строк; если ее нет — это потерянная сессия, пустой или медленный
+	// проект либо изменившийся UI. Что именно, решает checkPageSelectors
+	waitErr := page.Locator(sel.Editor.Row).First().WaitFor()
+	if err := checkPageSelectors(page, sel, e.config.TargetLangIDs, waitErr); err != nil {
+		return "", e.sessionError(err)
+	}
+
+	filename, err := page.Locator(sel.Editor.Filename).InnerText()
+	if err != nil {
+		return "", e.sessionError(fmt.Errorf("could not get filename: %v", err))
+	}
+	// Очистка имени файла от неразрывных пробелов и лишних символов
+	filename = strings.TrimSpace(strings.ReplaceAll(filename, "\u00a0", " "))
+	filename = strings.TrimPrefix(filename, "Filename: ")
+	e.filename = strings.TrimSpace(filename)
+
+	return e.filename, nil
+}
+
+func (e *browserEditor) Collect(ctx context.Context) ([]TranslationItem, error) {
+	items, err := scrollAndCollect(ctx, e.page, e.config, e.filename)
+	return items, e.sessionError(err)
+}
+
+func (e *browserEditor) Fill(ctx context.Context, items []TranslationItem, journal fillJournal) (fillResult, error) {
+	result, err := fillTranslations(ctx, e.page, items, e.config, journal)
+	return result, e.sessionError(err)
+}
+
+// sessionError помечает ошибку как истекшую сессию, если страница ушла на вход
+// или вместо редактора показала форму входа.
+func (e *browserEditor) sessionError(err error) error {
+	if err == nil {
+		return nil
+	}
+	if isSignInURL(e.page.URL()) {
+		return fmt.Errorf("%w: %v", errSessionExpired, err)
+	}
+	if visible, _ := e.page.Locator(e.config.Selectors.Login.Password).First().IsVisible(); visible {
+		return fmt.Errorf("%w: %v", errSessionExpired, err)
+	}
+	return err
+}
+
+func (e *browserEditor) Close() {
+	e.closeOnce.Do(func() {
+		if e.browserCtx != nil {
+			e.browserCtx.Close()
+		}
+	})
+}
+
+// Diagnose сохраняет снимок экрана, HTML страницы и трейс Playwright.
+// Ошибки не прерывают сбор: сохраняется все, что удалось.
+func (e *browserEditor) Diagnose(dir string) error {
+	if e.page == nil {
+		return fmt.Errorf("page is not open")
+	}
+
+	var errs []error
+	_, err := e.page.Screenshot(playwright.PageScreenshotOptions{
+		Path:     playwright.String(filepath.Join(dir, diagScreenshotFile)),
+		FullPage: playwright.Bool(true),
+	})
+	if err != nil {
+		errs = append(errs, fmt.Errorf("screenshot: %v", err))
+	}
+
+	html, err := e.page.Content()
+	if err == nil {
+		err = os.WriteFile(filepath.Join(dir, diagPageFile), []byte(html), 0644)
+	}
+	if err != nil {
+		errs = append(errs, fmt.Errorf("page html: %v", err))
+	}
+
+	if e.config.Trace {
+		if err := e.browserCtx.Tracing().Stop(filepath.Join(dir, diagTraceFile)); err != nil {
+			errs = append(errs, fmt.Errorf("trace: %v", err))
+		}
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,157 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+)
+
+// Структуры для Gemini API
+type GeminiPart struct {
+	Text string `json:"text"`
+}
+
+type GeminiContent struct {
+	Parts []GeminiPart `json:"parts"`
+}
+
+type GeminiGenerationConfig struct {
+	ResponseMimeType string          `json:"responseMimeType,omitempty"`
+	ResponseSchema   json.RawMessage `json:"responseSchema,omitempty"`
+}
+
+type GeminiPayload struct {
+	Contents         []GeminiContent        `json:"contents"`
+	GenerationConfig GeminiGenerationConfig `json:"generationConfig"`
+}
+
+type GeminiCandidate struct {
+	Content      GeminiContent `json:"content"`
+	FinishReason string        `json:"finishReason"`
+}
+
+type GeminiGenerateResponse struct {
+	Candidates     []GeminiCandidate `json:"candidates"`
+	PromptFeedback *struct {
+		BlockReason string `json:"blockReason"`
+	} `json:"promptFeedback,omitempty"`
+}
+
+// geminiResponseSchema описывает GeminiResponse для structured output:
+// модель обязана вернуть ровно такой JSON, без Markdown и пояснений.
+var geminiResponseSchema = json.RawMessage(`{
+	"type": "OBJECT",
+	"properties": {
+		"results": {
+			"type": "ARRAY",
+			"items": {
+				"type": "OBJECT",
+				"properties": {
+					"id": {"type": "STRING"},
+					"translation": {"type": "STRING"}
+				},
+				"required": ["id", "translation"]
+			}
+		}
+	},
+	"required": ["results"]
+}`)
+
+// geminiFinishError — кандидат завершился не штатно (блокировка, обрыв по лимиту токенов и т.п.).
+type geminiFinishError struct {
+	Reason string
+}
+
+func (e *geminiFinishError) Error() string {
+	switch e.Reason {
+	case "MAX_TOKENS":
+		return "gemini response truncated (MAX_TOKENS)"
+	case "SAFETY", "PROHIBITED_CONTENT", "BLOCKLIST", "SPII":
+		return fmt.Sprintf("gemini blocked the response by safety filters (%s)", e.Reason)
+	case "RECITATION":
+		return "gemini blocked the response as recitation of copyrighted text (RECITATION)"
+	default:
+		return fmt.Sprintf("gemini finished with reason %s", e.Reason)
+	}
+}
+
+// geminiTranslator — реализация Translator поверх Gemini generateContent.
+type geminiTranslator struct {
+	config Config
+}
+
+func (t *geminiTranslator) Translate(ctx context.Context, items []TranslationItem) ([]TranslationItem, error) {
+	return translateWithGemini(ctx, items, t.config)
+}
+
+func translateWithGemini(ctx context.Context, tmap []TranslationItem, config Config) ([]TranslationItem, error) {
+	slog.InfoContext(ctx, "⏳ Запрос к Gemini...")
+
+	geminiReq := GeminiPayload{
+		Contents: []GeminiContent{
+			{Parts: []GeminiPart{{Text: buildPrompt(tmap, config)}}},
+		},
+		GenerationConfig: GeminiGenerationConfig{
+			ResponseMimeType: "application/json",
+			ResponseSchema:   geminiResponseSchema,
+		},
+	}
+
+	jsonPayload, _ := json.Marshal(geminiReq)
+	// responseSchema поддерживается в v1beta
+	// Ключ уходит заголовком, а не в ?key=: URL попадает в текст сетевых ошибок,
+	// а значит, в лог повторов и в log-tail.txt диагностики
+	url := fmt.Sprintf("https://generativelanguage.googleapis.com/v1beta/models/%s:generateContent", config.Model)
+
+	body, err := doWithRetry(ctx, config, func() (*http.Request, error) {
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(jsonPayload))
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("x-goog-api-key", config.GeminiAPIKey)
+		return req, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// --- ВЫВОД RAW ОТВЕТА В КОНСОЛЬ ---
+	// fmt.Printf("\n[RAW LLM RESPONSE]:\n%s\n\n", string(body))
+
+	var genResp GeminiGenerateResponse
+	if err := json.Unmarshal(body, &genResp); err != nil {
+		return nil, fmt.Errorf("invalid response format: %w", err)
+	}
+
+	if genResp.PromptFeedback != nil && genResp.PromptFeedback.BlockReason != "" {
+		return nil, &geminiFinishError{Reason: genResp.PromptFeedback.BlockReason}
+	}
+	if len(genResp.Candidates) == 0 {
+		return nil, fmt.Errorf("no candidates in response: %s", string(body))
+	}
+
+	candidate := genResp.Candidates[0]
+	if candidate.FinishReason != "" && candidate.FinishReason != "STOP" {
+		return nil, &geminiFinishError{Reason: candidate.FinishReason}
+	}
+
+	var text strings.Builder
+	for _, part := range candidate.Content.Parts {
+		text.WriteString(part.Text)
+	}
+	if text.Len() == 0 {
+		return nil, fmt.Errorf("empty candidate in response: %s", string(body))
+	}
+
+	var finalResp GeminiResponse
+	if err := json.Unmarshal([]byte(text.String()), &finalResp); err != nil {
+		return nil, fmt.Errorf("Не удалось распарсить ответ от gemini: %w \nТекст ответа: %s", err, text.String())
+	}
+
+	return finalResp.Results, nil
+}
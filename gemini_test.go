@@ -0,0 +1,90 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+// withGeminiStub направляет запросы к generativelanguage.googleapis.com на заглушку,
+// которая отвечает body и сохраняет тело запроса в payload.
+func withGeminiStub(t *testing.T, body string, payload *GeminiPayload) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if payload != nil {
+			if err := json.NewDecoder(r.Body).Decode(payload); err != nil {
+				t.Errorf("decode payload: %v", err)
+			}
+		}
+		w.Write([]byte(body))
+	}))
+	t.Cleanup(server.Close)
+
+	target, _ := url.Parse(server.URL)
+	transport := http.DefaultClient.Transport
+	http.DefaultClient.Transport = rewriteTransport{target: target}
+	t.Cleanup(func() { http.DefaultClient.Transport = transport })
+}
+
+func TestTranslateWithGeminiFinishReasons(t *testing.T) {
+	const results = `{\"results\": [{\"id\": \"1\", \"translation\": \"Cześć\"}]}`
+	tests := []struct {
+		name       string
+		body       string
+		wantReason string // ожидаемый geminiFinishError
+		wantErr    string // или текст другой ошибки
+	}{
+		{"stop", `{"candidates": [{"content": {"parts": [{"text": "` + results + `"}]}, "finishReason": "STOP"}]}`, "", ""},
+		{"split parts", `{"candidates": [{"content": {"parts": [{"text": "{\"results\": [{\"id\": \"1\", "}, {"text": "\"translation\": \"Cześć\"}]}"}]}, "finishReason": "STOP"}]}`, "", ""},
+		{"max tokens", `{"candidates": [{"content": {"parts": [{"text": "{\"results\": [{\"id\""}]}, "finishReason": "MAX_TOKENS"}]}`, "MAX_TOKENS", "truncated"},
+		{"safety", `{"candidates": [{"finishReason": "SAFETY"}]}`, "SAFETY", "safety filters"},
+		{"recitation", `{"candidates": [{"finishReason": "RECITATION"}]}`, "RECITATION", "recitation"},
+		{"unknown reason", `{"candidates": [{"finishReason": "OTHER"}]}`, "OTHER", "reason OTHER"},
+		{"blocked prompt", `{"promptFeedback": {"blockReason": "PROHIBITED_CONTENT"}}`, "PROHIBITED_CONTENT", "safety filters"},
+		{"no candidates", `{"candidates": []}`, "", "no candidates"},
+		{"empty candidate", `{"candidates": [{"content": {"parts": []}, "finishReason": "STOP"}]}`, "", "empty candidate"},
+		{"not json", `{"candidates": [{"content": {"parts": [{"text": "Sure! Here you go"}]}, "finishReason": "STOP"}]}`, "", "Не удалось распарсить"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			withGeminiStub(t, tt.body, nil)
+
+			got, err := translateWithGemini(context.Background(), []TranslationItem{{ID: "1", Original: "Hello"}}, Config{Model: "gemini-test"})
+			if tt.wantReason == "" && tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("translateWithGemini: %v", err)
+				}
+				if len(got) != 1 || got[0].ID != "1" || got[0].Translation != "Cześć" {
+					t.Errorf("got %+v", got)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("err = %v, want %q", err, tt.wantErr)
+			}
+			var fe *geminiFinishError
+			if isFinish := errors.As(err, &fe); isFinish != (tt.wantReason != "") || (isFinish && fe.Reason != tt.wantReason) {
+				t.Errorf("err = %#v, want geminiFinishError %q", err, tt.wantReason)
+			}
+		})
+	}
+}
+
+func TestTranslateWithGeminiRequestsSchema(t *testing.T) {
+	var payload GeminiPayload
+	withGeminiStub(t, `{"candidates": [{"content": {"parts": [{"text": "{\"results\": []}"}]}, "finishReason": "STOP"}]}`, &payload)
+
+	if _, err := translateWithGemini(context.Background(), []TranslationItem{{ID: "1", Original: "Hello"}}, Config{Model: "gemini-test"}); err != nil {
+		t.Fatalf("translateWithGemini: %v", err)
+	}
+	if payload.GenerationConfig.ResponseMimeType != "application/json" {
+		t.Errorf("responseMimeType = %q", payload.GenerationConfig.ResponseMimeType)
+	}
+	if !strings.Contains(string(payload.GenerationConfig.ResponseSchema), `"results"`) {
+		t.Errorf("responseSchema = %s", payload.GenerationConfig.ResponseSchema)
+	}
+}
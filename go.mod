@@ -7,6 +7,7 @@ require (
 	github.com/playwright-community/playwright-go v0.5200.1
 	github.com/sirupsen/logrus v1.6.0
 	gopkg.in/telebot.v4 v4.0.0-beta.7
+	gopkg.in/yaml.v3 v3.0.1
 )
 
 require (
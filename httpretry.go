@@ -0,0 +1,172 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"math/rand/v2"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// Верхняя граница паузы между повторами, даже если Retry-After просит больше
+const maxRetryDelay = 2 * time.Minute
+
+// apiError — ответ API с неуспешным HTTP-статусом.
+type apiError struct {
+	StatusCode int
+	Message    string
+	RetryAfter time.Duration
+}
+
+func (e *apiError) Error() string {
+	switch e.StatusCode {
+	case http.StatusBadRequest:
+		return fmt.Sprintf("bad request (400), check model name and payload: %s", e.Message)
+	case http.StatusUnauthorized, http.StatusForbidden:
+		return fmt.Sprintf("access denied (%d), check API key and its permissions: %s", e.StatusCode, e.Message)
+	case http.StatusNotFound:
+		return fmt.Sprintf("not found (404), check model name and base URL: %s", e.Message)
+	case http.StatusTooManyRequests:
+		return fmt.Sprintf("rate limited (429): %s", e.Message)
+	default:
+		return fmt.Sprintf("http status %d: %s", e.StatusCode, e.Message)
+	}
+}
+
+// retryable — временные ошибки (квота, перегрузка сервера), которые имеет смысл повторить.
+func (e *apiError) retryable() bool {
+	switch e.StatusCode {
+	case http.StatusTooManyRequests,
+		http.StatusInternalServerError,
+		http.StatusBadGateway,
+		http.StatusServiceUnavailable,
+		http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
+
+// doWithRetry выполняет запрос, повторяя временные ошибки с экспоненциальной
+// паузой и джиттером. newRequest вызывается на каждую попытку, потому что тело
+// запроса нельзя прочитать дважды. Возвращает тело успешного (2xx) ответа.
+func doWithRetry(ctx context.Context, config Config, newRequest func() (*http.Request, error)) ([]byte, error) {
+	var lastErr error
+	for attempt := 0; attempt <= config.MaxRetries; attempt++ {
+		if attempt > 0 {
+			delay := backoffDelay(config.RetryBaseDelay, attempt)
+			var apiErr *apiError
+			if errors.As(lastErr, &apiErr) && apiErr.RetryAfter > 0 {
+				delay = min(apiErr.RetryAfter, maxRetryDelay)
+			}
+			slog.WarnContext(ctx, "🔁 Повтор запроса", "attempt", attempt, "of", config.MaxRetries, "delay", delay, "error", lastErr)
+
+			select {
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			case <-time.After(delay):
+			}
+		}
+
+		req, err := newRequest()
+		if err != nil {
+			return nil, err
+		}
+
+		body, err := doOnce(req)
+		if err == nil {
+			return body, nil
+		}
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+
+		var apiErr *apiError
+		if errors.As(err, &apiErr) && !apiErr.retryable() {
+			return nil, err
+		}
+		lastErr = err
+	}
+	return nil, fmt.Errorf("giving up after %d retries: %w", config.MaxRetries, lastErr)
+}
+
+func doOnce(req *http.Request) ([]byte, error) {
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return nil, &apiError{
+			StatusCode: resp.StatusCode,
+			Message:    apiErrorMessage(body),
+			RetryAfter: parseRetryAfter(resp.Header.Get("Retry-After")),
+		}
+	}
+	return body, nil
+}
+
+// backoffDelay — base * 2^(attempt-1) плюс случайные ±50%, не больше maxRetryDelay.
+func backoffDelay(base time.Duration, attempt int) time.Duration {
+	delay := base << (attempt - 1)
+	if delay <= 0 || delay > maxRetryDelay {
+		delay = maxRetryDelay
+	}
+	jitter := time.Duration(rand.Int64N(int64(delay)+1)) - delay/2
+	return min(delay+jitter, maxRetryDelay)
+}
+
+// parseRetryAfter понимает оба формата заголовка: секунды и HTTP-дату.
+func parseRetryAfter(value string) time.Duration {
+	if value == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(value); err == nil && seconds > 0 {
+		return time.Duration(seconds) * time.Second
+	}
+	if t, err := http.ParseTime(value); err == nil {
+		return max(time.Until(t), 0)
+	}
+	return 0
+}
+
+// apiErrorMessage достает текст ошибки из тела ответа. Понимает форматы
+// Gemini/OpenAI ({"error": {"message": ...}}), Ollama ({"error": "..."}) и DeepL ({"message": ...}).
+func apiErrorMessage(body []byte) string {
+	var nested struct {
+		Error struct {
+			Message string `json:"message"`
+			Status  string `json:"status"`
+		} `json:"error"`
+	}
+	if json.Unmarshal(body, &nested) == nil && nested.Error.Message != "" {
+		if nested.Error.Status != "" {
+			return nested.Error.Status + ": " + nested.Error.Message
+		}
+		return nested.Error.Message
+	}
+
+	var flat struct {
+		Error   string `json:"error"`
+		Message string `json:"message"`
+	}
+	if json.Unmarshal(body, &flat) == nil {
+		if flat.Error != "" {
+			return flat.Error
+		}
+		if flat.Message != "" {
+			return flat.Message
+		}
+	}
+	return string(body)
+}
@@ -0,0 +1,145 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestParseRetryAfter(t *testing.T) {
+	tests := []struct {
+		value string
+		min   time.Duration
+		max   time.Duration
+	}{
+		{"", 0, 0},
+		{"7", 7 * time.Second, 7 * time.Second},
+		{"0", 0, 0},
+		{"-3", 0, 0},
+		{"soon", 0, 0},
+		{time.Now().Add(30 * time.Second).UTC().Format(http.TimeFormat), 25 * time.Second, 30 * time.Second},
+		{time.Now().Add(-time.Hour).UTC().Format(http.TimeFormat), 0, 0},
+	}
+	for _, tt := range tests {
+		got := parseRetryAfter(tt.value)
+		if got < tt.min || got > tt.max {
+			t.Errorf("parseRetryAfter(%q) = %v, want %v..%v", tt.value, got, tt.min, tt.max)
+		}
+	}
+}
+
+func TestBackoffDelay(t *testing.T) {
+	base := 2 * time.Second
+	for attempt := 1; attempt <= 10; attempt++ {
+		nominal := min(base<<(attempt-1), maxRetryDelay)
+		for range 20 {
+			got := backoffDelay(base, attempt)
+			if got < nominal/2 || got > maxRetryDelay {
+				t.Fatalf("backoffDelay(%v, %d) = %v, want %v..%v", base, attempt, got, nominal/2, maxRetryDelay)
+			}
+		}
+	}
+}
+
+func TestApiErrorMessage(t *testing.T) {
+	tests := []struct {
+		body string
+		want string
+	}{
+		{`{"error": {"code": 400, "message": "API key not valid", "status": "INVALID_ARGUMENT"}}`, "INVALID_ARGUMENT: API key not valid"},
+		{`{"error": {"message": "model not found"}}`, "model not found"},
+		{`{"error": "model 'llama' not found"}`, "model 'llama' not found"},
+		{`{"message": "Wrong endpoint"}`, "Wrong endpoint"},
+		{`Bad Gateway`, "Bad Gateway"},
+	}
+	for _, tt := range tests {
+		if got := apiErrorMessage([]byte(tt.body)); got != tt.want {
+			t.Errorf("apiErrorMessage(%s) = %q, want %q", tt.body, got, tt.want)
+		}
+	}
+}
+
+func TestDoWithRetry(t *testing.T) {
+	tests := []struct {
+		name      string
+		statuses  []int
+		wantCalls int32
+		wantErr   bool
+	}{
+		{"success", []int{200}, 1, false},
+		{"retries 503 and 429", []int{503, 429, 200}, 3, false},
+		{"does not retry 400", []int{400, 200}, 1, true},
+		{"does not retry 401", []int{401, 200}, 1, true},
+		{"gives up", []int{500, 500, 500, 500}, 3, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var calls atomic.Int32
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				status := tt.statuses[calls.Add(1)-1]
+				w.WriteHeader(status)
+				w.Write([]byte(`{"error": {"message": "status ` + http.StatusText(status) + `"}}`))
+			}))
+			defer server.Close()
+
+			config := Config{MaxRetries: 2, RetryBaseDelay: time.Millisecond}
+			_, err := doWithRetry(context.Background(), config, func() (*http.Request, error) {
+				return http.NewRequest(http.MethodGet, server.URL, nil)
+			})
+			if (err != nil) != tt.wantErr {
+				t.Errorf("err = %v, wantErr %v", err, tt.wantErr)
+			}
+			if calls.Load() != tt.wantCalls {
+				t.Errorf("calls = %d, want %d", calls.Load(), tt.wantCalls)
+			}
+		})
+	}
+}
+
+// Ключ Gemini не должен попадать в URL: текст сетевой ошибки содержит URL
+// и уходит в лог повторов и в диагностику.
+func TestGeminiKeyNotInURL(t *testing.T) {
+	var gotURL, gotKey string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotURL = r.URL.String()
+		gotKey = r.Header.Get("x-goog-api-key")
+		w.Write([]byte(`{"candidates": [{"content": {"parts": [{"text": "{\"results\": [{\"id\": \"1\", \"translation\": \"Cześć\"}]}"}]}, "finishReason": "STOP"}]}`))
+	}))
+	defer server.Close()
+
+	// Подменяем транспорт: запрос к generativelanguage.googleapis.com уходит на заглушку
+	target, _ := url.Parse(server.URL)
+	transport := http.DefaultClient.Transport
+	http.DefaultClient.Transport = rewriteTransport{target: target}
+	defer func() { http.DefaultClient.Transport = transport }()
+
+	config := Config{GeminiAPIKey: "secret-key", Model: "gemini-test"}
+	if _, err := translateWithGemini(context.Background(), []TranslationItem{{ID: "1", Original: "Hello"}}, config); err != nil {
+		t.Fatalf("translateWithGemini: %v", err)
+	}
+	if strings.Contains(gotURL, "secret-key") {
+		t.Errorf("api key leaked into url %q", gotURL)
+	}
+	if gotKey != "secret-key" {
+		t.Errorf("x-goog-api-key = %q", gotKey)
+	}
+}
+
+type rewriteTransport struct {
+	target *url.URL
+}
+
+func (t rewriteTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.URL.Host != "generativelanguage.googleapis.com" {
+		return nil, errors.New("unexpected host " + req.URL.Host)
+	}
+	req = req.Clone(req.Context())
+	req.URL.Scheme, req.URL.Host = t.target.Scheme, t.target.Host
+	return http.DefaultTransport.RoundTrip(req)
+}
@@ -0,0 +1,48 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+
+	"github.com/playwright-community/playwright-go"
+)
+
+// Способы ввода текста в открытый редактор ячейки (insert_strategy в профиле селекторов)
+const (
+	// Посимвольный набор: медленно на длинных абзацах и может вызвать автодополнение
+	insertStrategyType = "type"
+	// Keyboard.InsertText: весь текст одним событием ввода, как при вставке, но без
+	// буфера обмена — он общий у всех окон браузера (а в обычном режиме и у всей системы)
+	insertStrategyInsert = "insert"
+	// Locator.Fill по полю ввода редактора (textarea или скрытое поле ACE)
+	insertStrategyFill = "fill"
+)
+
+var insertStrategies = []string{insertStrategyType, insertStrategyInsert, insertStrategyFill}
+
+// insertText вводит текст в открытый редактор выбранным способом.
+// Если быстрый способ не сработал, текст набирается посимвольно.
+func insertText(ctx context.Context, page playwright.Page, sel EditorSelectors, strategy, text string) error {
+	var err error
+	switch strategy {
+	case insertStrategyInsert:
+		err = page.Keyboard().InsertText(text)
+	case insertStrategyFill:
+		err = page.Locator(sel.OpenedEditor).First().Fill(text)
+	default:
+		return typeText(page, text)
+	}
+	if err == nil {
+		return nil
+	}
+	slog.WarnContext(ctx, "⌨️ Быстрая вставка не сработала, набираем текст", "strategy", strategy, "error", err)
+	return typeText(page, text)
+}
+
+func typeText(page playwright.Page, text string) error {
+	if err := page.Keyboard().Type(text); err != nil {
+		return errors.New("could not type translation: " + err.Error())
+	}
+	return nil
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/playwright-community/playwright-go"
+)
+
+// Сколько ждать редиректа со страницы входа после отправки формы
+const loginTimeout = 60 * time.Second
+
+// scriptedLogin входит без участия человека: email, пароль и, если сайт
+// попросит, код 2FA из LOKALISE_TOTP_SECRET.
+func scriptedLogin(page playwright.Page, config Config) error {
+	slog.Info("🤖 Вход по логину и паролю", "email", config.LoginEmail)
+	// Селекторы профиля подходят и для Lokalise, и для тестовой страницы из testdata/fake-signin
+	sel := config.Selectors.Login
+
+	if err := page.Locator(sel.Email).First().Fill(config.LoginEmail); err != nil {
+		return fmt.Errorf("could not fill email: %v", err)
+	}
+
+	// Форма бывает двухшаговой: сначала email, потом пароль
+	password := page.Locator(sel.Password).First()
+	if visible, _ := password.IsVisible(); !visible {
+		if err := page.Locator(sel.Submit).First().Click(); err != nil {
+			return fmt.Errorf("could not submit email: %v", err)
+		}
+		if err := password.WaitFor(playwright.LocatorWaitForOptions{State: playwright.WaitForSelectorStateVisible}); err != nil {
+			return fmt.Errorf("password field did not appear: %v", err)
+		}
+	}
+	if err := password.Fill(config.LoginPassword); err != nil {
+		return fmt.Errorf("could not fill password: %v", err)
+	}
+	if err := page.Locator(sel.Submit).First().Click(); err != nil {
+		return fmt.Errorf("could not submit login form: %v", err)
+	}
+
+	// Ждем ухода со страницы входа; по пути может появиться поле кода 2FA
+	otpSent := false
+	for deadline := time.Now().Add(loginTimeout); time.Now().Before(deadline); {
+		if !isSignInURL(page.URL()) {
+			slog.Info("✅ Вход выполнен", "url", page.URL())
+			return nil
+		}
+
+		otp := page.Locator(sel.OTP).First()
+		if visible, _ := otp.IsVisible(); visible && !otpSent {
+			if config.TOTPSecret == "" {
+				return errors.New("2FA code requested but LOKALISE_TOTP_SECRET is not set")
+			}
+			code, err := totpCode(config.TOTPSecret, time.Now())
+			if err != nil {
+				return err
+			}
+			if err := otp.Fill(code); err != nil {
+				return fmt.Errorf("could not fill 2FA code: %v", err)
+			}
+			if err := page.Locator(sel.Submit).First().Click(); err != nil {
+				return fmt.Errorf("could not submit 2FA code: %v", err)
+			}
+			otpSent = true
+		}
+		time.Sleep(500 * time.Millisecond)
+	}
+	return fmt.Errorf("still on sign-in page after %s, check LOKALISE_EMAIL/LOKALISE_PASSWORD/LOKALISE_TOTP_SECRET", loginTimeout)
+}
@@ -0,0 +1,275 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Лимиты Lokalise API: до 500 ключей на страницу и в одном bulk update
+const lokaliseMaxKeys = 500
+
+var lokaliseProjectIDPattern = regexp.MustCompile(`/project/([^/?#]+)`)
+
+// Структуры для Lokalise REST API v2
+type LokaliseProject struct {
+	ProjectID       string `json:"project_id"`
+	Name            string `json:"name"`
+	BaseLanguageID  int64  `json:"base_language_id"`
+	BaseLanguageISO string `json:"base_language_iso"`
+}
+
+type LokaliseLanguagesResponse struct {
+	Languages []struct {
+		LangID  int64  `json:"lang_id"`
+		LangISO string `json:"lang_iso"`
+	} `json:"languages"`
+}
+
+type LokaliseTranslation struct {
+	LanguageISO string `json:"language_iso"`
+	Translation string `json:"translation"`
+}
+
+type LokaliseKey struct {
+	KeyID        int64                 `json:"key_id"`
+	IsPlural     bool                  `json:"is_plural,omitempty"`
+	Translations []LokaliseTranslation `json:"translations"`
+}
+
+type LokaliseKeysResponse struct {
+	Keys   []LokaliseKey      `json:"keys"`
+	Errors []LokaliseKeyError `json:"errors,omitempty"`
+}
+
+// LokaliseKeyError — ключ, который bulk update не принял. Сам ответ при этом 200.
+type LokaliseKeyError struct {
+	Message string `json:"message"`
+	Code    int    `json:"code"`
+	KeyID   int64  `json:"key_id"`
+	Key     struct {
+		KeyID int64 `json:"key_id"`
+	} `json:"key"`
+}
+
+// keyID — ID отклоненного ключа: Lokalise кладет его либо в key_id, либо в key.
+func (e LokaliseKeyError) keyID() string {
+	if e.KeyID == 0 {
+		return strconv.FormatInt(e.Key.KeyID, 10)
+	}
+	return strconv.FormatInt(e.KeyID, 10)
+}
+
+// apiEditor работает с проектом через Lokalise REST API, без браузера:
+// без прокрутки виртуальной таблицы и посимвольного ввода.
+type apiEditor struct {
+	config     Config
+	projectID  string
+	project    LokaliseProject
+	targetISOs map[string]string // lang_id -> код языка в API
+}
+
+func (e *apiEditor) Open(ctx context.Context, projectURL string) (string, error) {
+	match := lokaliseProjectIDPattern.FindStringSubmatch(projectURL)
+	if match == nil {
+		return "", fmt.Errorf("could not find project id in url %q", projectURL)
+	}
+	e.projectID = match[1]
+
+	if err := e.get(ctx, "/projects/"+e.projectID, nil, &e.project); err != nil {
+		return "", fmt.Errorf("could not get project: %v", err)
+	}
+
+	var languages LokaliseLanguagesResponse
+	if err := e.get(ctx, "/projects/"+e.projectID+"/languages", url.Values{"limit": {strconv.Itoa(lokaliseMaxKeys)}}, &languages); err != nil {
+		return e.project.Name, fmt.Errorf("could not get languages: %v", err)
+	}
+	isoByID := make(map[string]string, len(languages.Languages))
+	for _, lang := range languages.Languages {
+		isoByID[strconv.FormatInt(lang.LangID, 10)] = lang.LangISO
+	}
+	e.targetISOs = make(map[string]string, len(e.config.TargetLangIDs))
+	for _, langID := range e.config.TargetLangIDs {
+		iso, ok := isoByID[langID]
+		if !ok {
+			return e.project.Name, fmt.Errorf("language %s not found in project", langID)
+		}
+		e.targetISOs[langID] = iso
+	}
+
+	return e.project.Name, nil
+}
+
+func (e *apiEditor) Collect(ctx context.Context) ([]TranslationItem, error) {
+	slog.InfoContext(ctx, "🔍 Начинаю поиск пустых строк через API", "project", e.project.Name)
+
+	var results []TranslationItem
+	checked := 0
+	for page := 1; ; page++ {
+		query := url.Values{
+			"include_translations":        {"1"},
+			"filter_translation_lang_ids": {fmt.Sprintf("%d,%s", e.project.BaseLanguageID, strings.Join(e.config.TargetLangIDs, ","))},
+			"limit":                       {strconv.Itoa(lokaliseMaxKeys)},
+			"page":                        {strconv.Itoa(page)},
+		}
+		var resp LokaliseKeysResponse
+		if err := e.get(ctx, "/projects/"+e.projectID+"/keys", query, &resp); err != nil {
+			return nil, fmt.Errorf("could not list keys: %v", err)
+		}
+
+		for _, key := range resp.Keys {
+			checked++
+			// Плюралы хранятся как JSON с формами — их переводим вручную
+			if key.IsPlural {
+				continue
+			}
+
+			byISO := make(map[string]string, len(key.Translations))
+			for _, t := range key.Translations {
+				byISO[t.LanguageISO] = t.Translation
+			}
+			original := strings.TrimSpace(byISO[e.project.BaseLanguageISO])
+			if original == "" {
+				continue
+			}
+			for _, langID := range e.config.TargetLangIDs {
+				if strings.TrimSpace(byISO[e.targetISOs[langID]]) == "" {
+					results = append(results, TranslationItem{
+						ID:       strconv.FormatInt(key.KeyID, 10),
+						LangID:   langID,
+						Original: original,
+					})
+				}
+			}
+		}
+
+		if len(resp.Keys) < lokaliseMaxKeys {
+			break
+		}
+	}
+
+	slog.InfoContext(ctx, "✅ Сбор данных завершен", "project", e.project.Name, "checked", checked, "collected", len(results))
+	return results, nil
+}
+
+func (e *apiEditor) Fill(ctx context.Context, items []TranslationItem, journal fillJournal) (fillResult, error) {
+	slog.InfoContext(ctx, "✍️ Вставка переводов через API...", "count", len(items))
+
+	result := fillResult{Total: len(items)}
+	for start := 0; start < len(items); start += lokaliseMaxKeys {
+		// Между пакетами проверяем остановку: отправленный пакет уже сохранен целиком
+		if err := ctx.Err(); err != nil {
+			return result, err
+		}
+		end := min(start+lokaliseMaxKeys, len(items))
+
+		// Переводы одной строки на разные языки уходят в одном ключе
+		var keys []LokaliseKey
+		keyIndex := make(map[string]int)
+		for _, item := range items[start:end] {
+			i, ok := keyIndex[item.ID]
+			if !ok {
+				keyID, err := strconv.ParseInt(item.ID, 10, 64)
+				if err != nil {
+					return result, fmt.Errorf("invalid key id %q: %v", item.ID, err)
+				}
+				i = len(keys)
+				keyIndex[item.ID] = i
+				keys = append(keys, LokaliseKey{KeyID: keyID})
+			}
+			keys[i].Translations = append(keys[i].Translations, LokaliseTranslation{
+				LanguageISO: e.targetISOs[item.LangID],
+				Translation: item.Translation,
+			})
+		}
+
+		payload, _ := json.Marshal(map[string][]LokaliseKey{"keys": keys})
+		// Начатый пакет доводим до конца даже после сигнала остановки
+		body, err := e.do(context.WithoutCancel(ctx), http.MethodPut, "/projects/"+e.projectID+"/keys", nil, payload)
+		if err != nil {
+			// Пакет не сохранился — отмечаем его строки пропущенными и идем дальше,
+			// следующий запуск отправит их заново
+			slog.ErrorContext(ctx, "⏭️ Пакет пропущен", "from", start+1, "to", end, "error", err)
+			for _, item := range items[start:end] {
+				result.Failed = append(result.Failed, rowFailure{Item: item, Err: err.Error()})
+			}
+			continue
+		}
+
+		var updated LokaliseKeysResponse
+		if err := json.Unmarshal(body, &updated); err != nil {
+			return result, fmt.Errorf("invalid response format: %s", string(body))
+		}
+		// Отклоненные ключи приходят в errors при статусе 200 — их строки не сохранены
+		rejected := make(map[string]string, len(updated.Errors))
+		for _, keyErr := range updated.Errors {
+			rejected[keyErr.keyID()] = fmt.Sprintf("%s (%d)", keyErr.Message, keyErr.Code)
+		}
+
+		// Ответ содержит обновленные ключи — по ним сверяем сохраненный текст с отправленным
+		saved := make(map[string]string)
+		for _, key := range updated.Keys {
+			for _, t := range key.Translations {
+				saved[strconv.FormatInt(key.KeyID, 10)+":"+t.LanguageISO] = t.Translation
+			}
+		}
+		for _, item := range items[start:end] {
+			if msg, ok := rejected[item.ID]; ok {
+				slog.ErrorContext(ctx, "⏭️ Lokalise не принял ключ", "id", item.ID, "lang_id", item.LangID, "error", msg)
+				result.Failed = append(result.Failed, rowFailure{Item: item, Err: msg})
+				continue
+			}
+			if e.config.VerifySave {
+				actual := saved[item.ID+":"+e.targetISOs[item.LangID]]
+				if normalizeCellText(actual) != normalizeCellText(item.Translation) {
+					slog.ErrorContext(ctx, "❌ Сохраненный текст не совпадает с переводом", "id", item.ID, "lang_id", item.LangID, "expected", item.Translation, "actual", actual)
+					result.Mismatched = append(result.Mismatched, rowMismatch{Item: item, Actual: actual})
+					continue
+				}
+			}
+			journal.MarkSaved(item)
+			result.Inserted++
+		}
+	}
+	return result, nil
+}
+
+func (e *apiEditor) Close() {}
+
+func (e *apiEditor) get(ctx context.Context, path string, query url.Values, out any) error {
+	body, err := e.do(ctx, http.MethodGet, path, query, nil)
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(body, out); err != nil {
+		return fmt.Errorf("invalid response format: %s", string(body))
+	}
+	return nil
+}
+
+func (e *apiEditor) do(ctx context.Context, method, path string, query url.Values, payload []byte) ([]byte, error) {
+	endpoint := strings.TrimRight(e.config.LokaliseAPIURL, "/") + path
+	if len(query) > 0 {
+		endpoint += "?" + query.Encode()
+	}
+
+	return doWithRetry(ctx, e.config, func() (*http.Request, error) {
+		req, err := http.NewRequestWithContext(ctx, method, endpoint, bytes.NewReader(payload))
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("X-Api-Token", e.config.LokaliseToken)
+		req.Header.Set("Accept", "application/json")
+		if payload != nil {
+			req.Header.Set("Content-Type", "application/json")
+		}
+		return req, nil
+	})
+}
@@ -0,0 +1,270 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// fakeLokalise — заглушка Lokalise API v2 для одного проекта с базовым en
+// и целевыми pl (748) и de (749).
+type fakeLokalise struct {
+	t    *testing.T
+	keys []LokaliseKey
+
+	mu   sync.Mutex
+	puts [][]LokaliseKey
+	// putResponse строит ответ на bulk update; по умолчанию Lokalise сохраняет все как есть
+	putResponse func(keys []LokaliseKey) LokaliseKeysResponse
+	putStatus   []int
+}
+
+func (f *fakeLokalise) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Header.Get("X-Api-Token") != "test-token" {
+		w.WriteHeader(http.StatusUnauthorized)
+		w.Write([]byte(`{"error": {"message": "Invalid ` + "`X-Api-Token`" + ` header", "code": 401}}`))
+		return
+	}
+
+	switch {
+	case r.Method == http.MethodGet && r.URL.Path == "/api2/projects/123.abc":
+		json.NewEncoder(w).Encode(LokaliseProject{ProjectID: "123.abc", Name: "Shop", BaseLanguageID: 640, BaseLanguageISO: "en"})
+	case r.Method == http.MethodGet && r.URL.Path == "/api2/projects/123.abc/languages":
+		w.Write([]byte(`{"languages": [{"lang_id": 640, "lang_iso": "en"}, {"lang_id": 748, "lang_iso": "pl"}, {"lang_id": 749, "lang_iso": "de"}]}`))
+	case r.Method == http.MethodGet && r.URL.Path == "/api2/projects/123.abc/keys":
+		if got := r.URL.Query().Get("filter_translation_lang_ids"); got != "640,748,749" {
+			f.t.Errorf("filter_translation_lang_ids = %q", got)
+		}
+		page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+		limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+		from := min((page-1)*limit, len(f.keys))
+		to := min(from+limit, len(f.keys))
+		json.NewEncoder(w).Encode(LokaliseKeysResponse{Keys: f.keys[from:to]})
+	case r.Method == http.MethodPut && r.URL.Path == "/api2/projects/123.abc/keys":
+		var payload struct {
+			Keys []LokaliseKey `json:"keys"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+			f.t.Errorf("decode bulk update: %v", err)
+		}
+		f.mu.Lock()
+		f.puts = append(f.puts, payload.Keys)
+		call := len(f.puts)
+		f.mu.Unlock()
+		if call <= len(f.putStatus) && f.putStatus[call-1] != http.StatusOK {
+			w.WriteHeader(f.putStatus[call-1])
+			w.Write([]byte(`{"error": {"message": "Bad request", "code": 400}}`))
+			return
+		}
+		resp := LokaliseKeysResponse{Keys: payload.Keys}
+		if f.putResponse != nil {
+			resp = f.putResponse(payload.Keys)
+		}
+		json.NewEncoder(w).Encode(resp)
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func newTestAPIEditor(t *testing.T, fake *fakeLokalise, config Config) *apiEditor {
+	t.Helper()
+	fake.t = t
+	server := httptest.NewServer(fake)
+	t.Cleanup(server.Close)
+
+	config.LokaliseAPIURL = server.URL + "/api2/"
+	config.LokaliseToken = "test-token"
+	if config.TargetLangIDs == nil {
+		config.TargetLangIDs = []string{"748", "749"}
+	}
+	editor := &apiEditor{config: config}
+	name, err := editor.Open(context.Background(), "https://app.lokalise.com/project/123.abc/?view=multi")
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	if name != "Shop" {
+		t.Errorf("project name = %q", name)
+	}
+	return editor
+}
+
+func TestAPIEditorOpen(t *testing.T) {
+	tests := []struct {
+		name    string
+		url     string
+		token   string
+		langIDs []string
+		wantErr string
+	}{
+		{"no project id", "https://app.lokalise.com/projects", "test-token", []string{"748"}, "could not find project id"},
+		{"bad token", "https://app.lokalise.com/project/123.abc/", "wrong", []string{"748"}, "X-Api-Token"},
+		{"unknown language", "https://app.lokalise.com/project/123.abc/", "test-token", []string{"748", "999"}, "language 999 not found"},
+		{"ok", "https://app.lokalise.com/project/123.abc/", "test-token", []string{"749"}, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(&fakeLokalise{t: t})
+			defer server.Close()
+
+			editor := &apiEditor{config: Config{LokaliseAPIURL: server.URL + "/api2", LokaliseToken: tt.token, TargetLangIDs: tt.langIDs}}
+			_, err := editor.Open(context.Background(), tt.url)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("Open: %v", err)
+				}
+				if editor.targetISOs["749"] != "de" {
+					t.Errorf("targetISOs = %v", editor.targetISOs)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("err = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestAPIEditorCollect(t *testing.T) {
+	translations := func(pairs ...string) []LokaliseTranslation {
+		var list []LokaliseTranslation
+		for i := 0; i < len(pairs); i += 2 {
+			list = append(list, LokaliseTranslation{LanguageISO: pairs[i], Translation: pairs[i+1]})
+		}
+		return list
+	}
+	fake := &fakeLokalise{keys: []LokaliseKey{
+		{KeyID: 1, Translations: translations("en", "Save", "pl", "", "de", "")},
+		{KeyID: 2, IsPlural: true, Translations: translations("en", `{"one": "item", "other": "items"}`, "pl", "", "de", "")},
+		{KeyID: 3, Translations: translations("en", "Cancel", "pl", "Anuluj", "de", " ")},
+		{KeyID: 4, Translations: translations("en", "", "pl", "", "de", "")},
+		{KeyID: 5, Translations: translations("en", "Done", "pl", "Gotowe", "de", "Fertig")},
+	}}
+	// Заполняем первую страницу целиком, чтобы Collect пошел за второй
+	for id := 100; len(fake.keys) < lokaliseMaxKeys+1; id++ {
+		fake.keys = append(fake.keys, LokaliseKey{KeyID: int64(id), Translations: translations("en", "Filled", "pl", "x", "de", "x")})
+	}
+	fake.keys = append(fake.keys, LokaliseKey{KeyID: 9999, Translations: translations("en", "Last page", "pl", "", "de", "Letzte Seite")})
+
+	editor := newTestAPIEditor(t, fake, Config{})
+	got, err := editor.Collect(context.Background())
+	if err != nil {
+		t.Fatalf("Collect: %v", err)
+	}
+	var keys []string
+	for _, item := range got {
+		keys = append(keys, item.key()+"="+item.Original)
+	}
+	want := []string{"748:1=Save", "749:1=Save", "749:3=Cancel", "748:9999=Last page"}
+	if !slices.Equal(keys, want) {
+		t.Errorf("collected %v, want %v", keys, want)
+	}
+}
+
+// memoryJournal — журнал вставки в памяти: ключи сохраненных строк по порядку.
+type memoryJournal struct {
+	attempted map[string]bool
+	saved     []string
+}
+
+func (j *memoryJournal) Attempted(item TranslationItem) bool { return j.attempted[item.key()] }
+
+func (j *memoryJournal) MarkAttempted(item TranslationItem) {
+	if j.attempted == nil {
+		j.attempted = make(map[string]bool)
+	}
+	j.attempted[item.key()] = true
+}
+
+func (j *memoryJournal) MarkSaved(item TranslationItem) { j.saved = append(j.saved, item.key()) }
+
+func TestAPIEditorFill(t *testing.T) {
+	items := []TranslationItem{
+		{ID: "1", LangID: "748", Translation: "Zapisz"},
+		{ID: "1", LangID: "749", Translation: "Speichern"},
+		{ID: "2", LangID: "748", Translation: "Anuluj"},
+		{ID: "3", LangID: "748", Translation: "Gotowe"},
+	}
+	// Ключ 2 отклонен в errors при статусе 200, ключ 3 сохранен с другим текстом
+	response := func(keys []LokaliseKey) LokaliseKeysResponse {
+		var resp LokaliseKeysResponse
+		for _, key := range keys {
+			switch key.KeyID {
+			case 2:
+				resp.Errors = append(resp.Errors, LokaliseKeyError{Message: "This key is locked", Code: 400, KeyID: 2})
+			case 3:
+				resp.Keys = append(resp.Keys, LokaliseKey{KeyID: 3, Translations: []LokaliseTranslation{{LanguageISO: "pl", Translation: "Zrobione"}}})
+			default:
+				resp.Keys = append(resp.Keys, key)
+			}
+		}
+		return resp
+	}
+
+	tests := []struct {
+		name           string
+		verifySave     bool
+		wantSaved      []string
+		wantFailed     []string
+		wantMismatched []string
+	}{
+		{"without verification", false, []string{"748:1", "749:1", "748:3"}, []string{"748:2"}, nil},
+		{"with verification", true, []string{"748:1", "749:1"}, []string{"748:2"}, []string{"748:3"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake := &fakeLokalise{putResponse: response}
+			editor := newTestAPIEditor(t, fake, Config{VerifySave: tt.verifySave})
+
+			journal := &memoryJournal{}
+			result, err := editor.Fill(context.Background(), items, journal)
+			if err != nil {
+				t.Fatalf("Fill: %v", err)
+			}
+			if saved := journal.saved; !slices.Equal(saved, tt.wantSaved) || result.Inserted != len(tt.wantSaved) || result.Total != len(items) {
+				t.Errorf("saved = %v (inserted %d of %d), want %v", saved, result.Inserted, result.Total, tt.wantSaved)
+			}
+			var failed, mismatched []string
+			for _, f := range result.Failed {
+				failed = append(failed, f.Item.key())
+				if !strings.Contains(f.Err, "This key is locked") {
+					t.Errorf("failure reason = %q", f.Err)
+				}
+			}
+			for _, m := range result.Mismatched {
+				mismatched = append(mismatched, m.Item.key())
+			}
+			if !slices.Equal(failed, tt.wantFailed) || !slices.Equal(mismatched, tt.wantMismatched) {
+				t.Errorf("failed = %v, mismatched = %v; want %v, %v", failed, mismatched, tt.wantFailed, tt.wantMismatched)
+			}
+
+			// Переводы одного ключа на разные языки уходят одним ключом
+			if len(fake.puts) != 1 || len(fake.puts[0]) != 3 || len(fake.puts[0][0].Translations) != 2 {
+				t.Errorf("bulk update payload = %+v", fake.puts)
+			}
+		})
+	}
+}
+
+func TestAPIEditorFillSkipsFailedChunk(t *testing.T) {
+	items := make([]TranslationItem, lokaliseMaxKeys+2)
+	for i := range items {
+		items[i] = TranslationItem{ID: strconv.Itoa(i + 1), LangID: "748", Translation: "Tekst"}
+	}
+	fake := &fakeLokalise{putStatus: []int{http.StatusBadRequest, http.StatusOK}}
+	editor := newTestAPIEditor(t, fake, Config{VerifySave: true})
+
+	journal := &memoryJournal{}
+	result, err := editor.Fill(context.Background(), items, journal)
+	if err != nil {
+		t.Fatalf("Fill: %v", err)
+	}
+	if saved := len(journal.saved); len(result.Failed) != lokaliseMaxKeys || saved != 2 || result.Inserted != 2 {
+		t.Errorf("failed = %d, saved = %d, inserted = %d; want %d, 2, 2", len(result.Failed), saved, result.Inserted, lokaliseMaxKeys)
+	}
+}
@@ -2,20 +2,23 @@ package main
 
 import (
 	"bufio"
-	"bytes"
-	"encoding/json"
+	"context"
 	"errors"
 	"fmt"
+	"html"
 	"io"
 	"log"
 	"log/slog"
-	"net/http"
 	"os"
+	"os/signal"
 	"path/filepath"
 	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
+	"syscall"
 	"time"
+	"unicode/utf8"
 
 	"github.com/joho/godotenv"
 	"github.com/playwright-community/playwright-go"
@@ -33,19 +36,63 @@ const AppVersion = "1.0.0"
 type Config struct {
 	GeminiAPIKey    string
 	InputFile       string
+	EditorMode      string
 	AuthStateFile   string
+	Headless        bool
+	LoginEmail      string
+	LoginPassword   string
+	TOTPSecret      string
+	StateDir        string
+	DryRun          bool
+	ReportDir       string
+	ReportFormat    string
+	Trace           bool
+	DiagLogLines    int
 	MaxConcurrency  int
-	TargetLangID    string
+	TargetLangIDs   []string
+	Translator      string
 	Model           string
+	OpenAIBaseURL   string
+	OpenAIAPIKey    string
+	OpenAIModel     string
+	OllamaURL       string
+	DeepLURL        string
+	DeepLAPIKey     string
+	DeepLSourceLang string
+	DeepLTargetLang string
+	GlossaryID      string
+	BatchMaxItems   int
+	BatchMaxTokens  int
+	BatchWorkers    int
+	MaxRetries      int
+	MissingRetries  int
+	QARetries       int
+	MinLengthRatio  float64
+	MaxLengthRatio  float64
+	RetryBaseDelay  time.Duration
 	Prompt          string
+	LangPrompts     map[string]string
+	Selectors       *SelectorProfile
 	TgBotToken      string
 	ChatId          string
 	BaseURL         string
+	LokaliseAPIURL  string
+	LokaliseToken   string
 	ScrollDelay     time.Duration
 	EditorLoadDelay time.Duration
 	FocusDelay      time.Duration
 	BeforeSaveDelay time.Duration
 	RowNextDelay    time.Duration
+	VerifySave      bool
+	VerifyRetries   int
+	RowRetries      int
+
+	// Таймауты проекта и его фаз (0 — без ограничения)
+	ProjectTimeout   time.Duration
+	CollectTimeout   time.Duration
+	TranslateTimeout time.Duration
+	FillTimeout      time.Duration
+	FillRowTimeout   time.Duration
 }
 
 func getScriptConfig() Config {
@@ -60,22 +107,73 @@ func getScriptConfig() Config {
 		os.Exit(1)
 	}
 	prompt := string(data)
+
+	selectors, err := loadSelectorProfile(getEnv("SELECTORS_FILE", "selectors/lokalise.yaml"))
+	if err != nil {
+		slog.Error("Failed to load selector profile", "error", err)
+		os.Exit(1)
+	}
 	return Config{
 		GeminiAPIKey:    os.Getenv("GEMINI_API_KEY"),
 		InputFile:       getEnv("INPUT_FILE", "projects.txt"),
+		EditorMode:      getEnv("EDITOR_MODE", editorModeBrowser),
 		AuthStateFile:   getEnv("AUTH_STATE_FILE", "auth.json"),
+		Headless:        getBoolEnv("HEADLESS", false),
+		LoginEmail:      getEnv("LOKALISE_EMAIL", ""),
+		LoginPassword:   getEnv("LOKALISE_PASSWORD", ""),
+		TOTPSecret:      getEnv("LOKALISE_TOTP_SECRET", ""),
+		StateDir:        getEnv("STATE_DIR", "state"),
+		DryRun:          getBoolEnv("DRY_RUN", false),
+		ReportDir:       getEnv("REPORT_DIR", "reports"),
+		ReportFormat:    getEnv("REPORT_FORMAT", reportFormatJSON),
+		Trace:           getBoolEnv("TRACE", false),
+		DiagLogLines:    getIntEnv("DIAG_LOG_LINES", 200),
 		MaxConcurrency:  getIntEnv("MAX_CONCURRENCY", 1),
-		TargetLangID:    getEnv("TARGET_LANG_ID", "748"),
+		TargetLangIDs:   getListEnv("TARGET_LANG_ID", "748"),
+		Translator:      getEnv("TRANSLATOR", "gemini"),
 		Model:           getEnv("MODEL", "gemini-2.5-flash"),
+		OpenAIBaseURL:   getEnv("OPENAI_BASE_URL", "https://api.openai.com/v1"),
+		OpenAIAPIKey:    getEnv("OPENAI_API_KEY", ""),
+		OpenAIModel:     getEnv("OPENAI_MODEL", ""),
+		OllamaURL:       getEnv("OLLAMA_URL", "http://localhost:11434"),
+		DeepLURL:        getEnv("DEEPL_URL", "https://api-free.deepl.com"),
+		DeepLAPIKey:     getEnv("DEEPL_API_KEY", ""),
+		DeepLSourceLang: getEnv("DEEPL_SOURCE_LANG", "EN"),
+		DeepLTargetLang: getEnv("DEEPL_TARGET_LANG", "PL"),
+		GlossaryID:      getEnv("DEEPL_GLOSSARY_ID", ""),
+		BatchMaxItems:   getIntEnv("BATCH_MAX_ITEMS", 100),
+		BatchMaxTokens:  getIntEnv("BATCH_MAX_TOKENS", 8000),
+		BatchWorkers:    getIntEnv("BATCH_CONCURRENCY", 1),
+		MaxRetries:      getIntEnv("HTTP_MAX_RETRIES", 5),
+		MissingRetries:  getIntEnv("RECONCILE_RETRIES", 2),
+		QARetries:       getIntEnv("QA_RETRIES", 2),
+		MinLengthRatio:  getFloatEnv("QA_MIN_LENGTH_RATIO", 0.5),
+		MaxLengthRatio:  getFloatEnv("QA_MAX_LENGTH_RATIO", 2.0),
+		RetryBaseDelay:  getDurationEnv("HTTP_RETRY_BASE_MS", 2000),
 		Prompt:          prompt,
+		LangPrompts:     readLangPrompts(getEnv("PROMPT_DIR", "prompts")),
+		Selectors:       selectors,
 		ScrollDelay:     getDurationEnv("SCROLL_DELAY_MS", 2000),
 		EditorLoadDelay: getDurationEnv("EDITOR_LOAD_DELAY_MS", 1500),
 		FocusDelay:      getDurationEnv("FOCUS_DELAY_MS", 300),
 		BeforeSaveDelay: getDurationEnv("BEFORE_SAVE_DELAY_MS", 800),
 		RowNextDelay:    getDurationEnv("ROW_NEXT_DELAY_MS", 600),
+		VerifySave:      getBoolEnv("VERIFY_SAVE", true),
+		VerifyRetries:   getIntEnv("VERIFY_RETRIES", 1),
+		RowRetries:      getIntEnv("ROW_RETRIES", 2),
 		TgBotToken:      getEnv("TG_BOT_TOKEN", ""),
 		ChatId:          getEnv("CHAT_ID", ""),
 		BaseURL:         getEnv("BASE_URL", "https://app.lokalise.com"),
+		LokaliseAPIURL:  getEnv("LOKALISE_API_URL", "https://api.lokalise.com/api2"),
+		LokaliseToken:   getEnv("LOKALISE_API_TOKEN", ""),
+
+		// Общий таймаут и таймаут вставки по умолчанию выключены: проект на тысячи
+		// строк вставляется часами. Вставку ограничивает FILL_ROW_TIMEOUT на строку
+		ProjectTimeout:   getTimeoutEnv("PROJECT_TIMEOUT", "0"),
+		CollectTimeout:   getTimeoutEnv("COLLECT_TIMEOUT", "30m"),
+		TranslateTimeout: getTimeoutEnv("TRANSLATE_TIMEOUT", "30m"),
+		FillTimeout:      getTimeoutEnv("FILL_TIMEOUT", "0"),
+		FillRowTimeout:   getTimeoutEnv("FILL_ROW_TIMEOUT", "1m"),
 	}
 }
 
@@ -95,6 +193,35 @@ func getIntEnv(key string, fallback int) int {
 	return fallback
 }
 
+// getListEnv читает список через запятую: "748,749,750".
+func getListEnv(key string, fallback string) []string {
+	var list []string
+	for _, value := range strings.Split(getEnv(key, fallback), ",") {
+		if value = strings.TrimSpace(value); value != "" {
+			list = append(list, value)
+		}
+	}
+	return list
+}
+
+func getBoolEnv(key string, fallback bool) bool {
+	if value, exists := os.LookupEnv(key); exists {
+		if b, err := strconv.ParseBool(value); err == nil {
+			return b
+		}
+	}
+	return fallback
+}
+
+func getFloatEnv(key string, fallback float64) float64 {
+	if value, exists := os.LookupEnv(key); exists {
+		if f, err := strconv.ParseFloat(value, 64); err == nil {
+			return f
+		}
+	}
+	return fallback
+}
+
 func getDurationEnv(key string, fallbackMs int) time.Duration {
 	if value, exists := os.LookupEnv(key); exists {
 		if ms, err := strconv.Atoi(value); err == nil {
@@ -104,23 +231,37 @@ func getDurationEnv(key string, fallbackMs int) time.Duration {
 	return time.Duration(fallbackMs) * time.Millisecond
 }
 
-// Структуры для Gemini API
-type GeminiPayload struct {
-	Contents []struct {
-		Parts []struct {
-			Text string `json:"text"`
-		} `json:"parts"`
-	} `json:"contents"`
+// getTimeoutEnv читает длительность в формате Go: "90s", "30m", "2h". "0" — без ограничения.
+func getTimeoutEnv(key, fallback string) time.Duration {
+	if d, err := time.ParseDuration(getEnv(key, fallback)); err == nil {
+		return d
+	}
+	d, _ := time.ParseDuration(fallback)
+	return d
 }
 
 type TranslationItem struct {
-	ID          string `json:"id"`
-	Original    string `json:"text"`
-	Translation string `json:"translation,omitempty"`
+	ID          string   `json:"id"`
+	LangID      string   `json:"lang_id,omitempty"` // data-lang-id колонки, куда вставлять перевод
+	Original    string   `json:"text"`
+	Translation string   `json:"translation,omitempty"`
+	Flags       []string `json:"flags,omitempty"` // причины, по которым QA отклонил перевод
 }
 
-type GeminiResponse struct {
-	Results []TranslationItem `json:"results"`
+// key — уникальный ключ строки в проекте: одна и та же строка переводится на несколько языков.
+func (t TranslationItem) key() string {
+	return t.LangID + ":" + t.ID
+}
+
+var logOptions = &slog.HandlerOptions{
+	Level: slog.LevelInfo,
+	// Можно добавить кастомный формат времени, если нужно
+	ReplaceAttr: func(groups []string, a slog.Attr) slog.Attr {
+		if a.Key == slog.TimeKey {
+			a.Value = slog.StringValue(a.Value.Time().Format("15:04:05"))
+		}
+		return a
+	},
 }
 
 func setupLogger() *os.File {
@@ -138,20 +279,11 @@ func setupLogger() *os.File {
 		log.Fatalf("Could not open log file: %v", err)
 	}
 
-	// Используем io.MultiWriter для записи и в файл, и в консоль
+	// Используем io.MultiWriter для записи в консоль и в файл
 	multiWriter := io.MultiWriter(os.Stdout, file)
 
-	// Настраиваем slog
-	handler := slog.NewTextHandler(multiWriter, &slog.HandlerOptions{
-		Level: slog.LevelInfo,
-		// Можно добавить кастомный формат времени, если нужно
-		ReplaceAttr: func(groups []string, a slog.Attr) slog.Attr {
-			if a.Key == slog.TimeKey {
-				a.Value = slog.StringValue(a.Value.Time().Format("15:04:05"))
-			}
-			return a
-		},
-	})
+	// Настраиваем slog; записи проекта дополнительно уходят в его буфер для диагностики сбоев
+	handler := projectLogHandler{slog.NewTextHandler(multiWriter, logOptions)}
 
 	logger := slog.New(handler)
 	slog.SetDefault(logger)
@@ -166,29 +298,101 @@ func main() {
 
 	slog.Info("🚀 Loka Translator Automation started", "version", AppVersion)
 	config := getScriptConfig()
+	if config.DryRun {
+		slog.Warn("🔎 Режим dry-run: переводы не будут вставлены в редактор", "reports", config.ReportDir)
+	}
 
-	// Запуск Playwright
-	pw, err := playwright.Run()
-	if err != nil {
-		slog.Error("could not start playwright", "error", err)
-		os.Exit(1)
+	// Ctrl+C / SIGTERM: дописываем текущую строку, сохраняем состояние и не берем новые проекты
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	go func() {
+		<-ctx.Done()
+		// Обычное завершение: ctx отменил stop() из defer, сигнала не было
+		if !isStopSignal(ctx) {
+			return
+		}
+		// Повторный сигнал завершает процесс сразу
+		stop()
+		slog.Warn("🛑 Получен сигнал остановки, дожидаемся сохранения текущих строк (повторный Ctrl+C — немедленный выход)",
+			"signal", context.Cause(ctx))
+	}()
+
+	// Браузер нужен только для работы через UI редактора
+	var browser playwright.Browser
+	if config.EditorMode != editorModeAPI {
+		// Запуск Playwright
+		pw, err := playwright.Run()
+		if err != nil {
+			slog.Error("could not start playwright", "error", err)
+			os.Exit(1)
+		}
+		defer pw.Stop()
+
+		// Запуск браузера
+		// Сигналы обрабатываем сами: браузер не должен закрыться посреди сохранения строки
+		browser, err = pw.Chromium.Launch(playwright.BrowserTypeLaunchOptions{
+			Headless:      playwright.Bool(config.Headless),
+			HandleSIGINT:  playwright.Bool(false),
+			HandleSIGTERM: playwright.Bool(false),
+		})
+		if err != nil {
+			slog.Error("could not launch browser", "error", err)
+			os.Exit(1)
+		}
+		defer browser.Close()
+
+		// Команда login: войти заново и обновить файл авторизации (проверка входа без обработки проектов)
+		if len(os.Args) > 1 && os.Args[1] == "login" {
+			if err := login(browser, config); err != nil {
+				slog.Error("Login failed", "error", err)
+				os.Exit(1)
+			}
+			return
+		}
+
+		// 1. Проверка авторизации
+		if err := ensureLogin(browser, config); err != nil {
+			slog.Error("Login failed", "error", err)
+			os.Exit(1)
+		}
 	}
-	defer pw.Stop()
 
-	// Запуск браузера
-	browser, err := pw.Chromium.Launch(playwright.BrowserTypeLaunchOptions{
-		Headless: playwright.Bool(false),
-	})
-	if err != nil {
-		slog.Error("could not launch browser", "error", err)
-		os.Exit(1)
+	if len(os.Args) > 1 && os.Args[1] == "login" {
+		slog.Warn("⚠️ Команда login нужна только для EDITOR_MODE=browser")
+		return
 	}
-	defer browser.Close()
 
-	// 1. Проверка авторизации
-	if err := ensureLogin(browser, config); err != nil {
-		slog.Error("Login failed", "error", err)
-		os.Exit(1)
+	// Команда import: вставить проверенный лингвистом файл, минуя сбор и перевод
+	if len(os.Args) > 1 && os.Args[1] == "import" {
+		if len(os.Args) != 4 {
+			fmt.Println("Использование: translator import <review.csv|review.xlsx|review.json> <project-url>")
+			os.Exit(2)
+		}
+		reviewPath, projectURL := os.Args[2], os.Args[3]
+		if err := config.checkLanguages(); err != nil {
+			slog.Error("Invalid language settings", "error", err)
+			os.Exit(1)
+		}
+		tgBot := newTgBot(config.TgBotToken)
+		sess := newSession(browser, config, tgBot)
+
+		filename, err := sess.process(projectURL, func() (string, error) {
+			return importReview(ctx, browser, reviewPath, projectURL, config)
+		})
+		if errors.Is(err, context.Canceled) {
+			slog.Warn("⏸️ Импорт прерван, прогресс сохранен — повторите команду для продолжения", "file", filename, "url", projectURL)
+			notifyTelegram(config, tgBot, fmt.Sprintf("⏸️ Импорт прерван:\n<a href=\"%s\">%s</a>", projectURL, filename))
+			os.Exit(1)
+		}
+		if err != nil {
+			slog.Error("❌ Ошибка импорта", "file", filename, "url", projectURL, "error", err)
+			logSelectorReport(err)
+			notifyTelegramError(config, tgBot, fmt.Sprintf("❌ Ошибка импорта:\n<a href=\"%s\">%s</a>", projectURL, filename), err)
+			os.Exit(1)
+		}
+		slog.Info("✅ Импорт завершен", "url", projectURL)
+		notifyTelegram(config, tgBot, fmt.Sprintf("✅ Импорт завершен:\n<a href=\"%s\">%s</a>", projectURL, filename))
+		return
 	}
 
 	// 2. Чтение списка проектов
@@ -204,25 +408,122 @@ func main() {
 
 	slog.Info("📋 Найдено проектов", "count", len(projects), "threads", config.MaxConcurrency)
 
-	// 3. Запуск воркеров
+	// Языки без своего промпта получили бы перевод на язык prompt.txt — проверяем до старта.
+	// Ошибки остальных настроек проекта (например, нет файла промпта) валят только его
+	languagesOK := true
+	for _, project := range projects {
+		projectConfig, err := project.apply(config)
+		if err != nil {
+			continue
+		}
+		if err := projectConfig.checkLanguages(); err != nil {
+			slog.Error("❌ Настройки языков не подходят для проекта", "url", project.URL, "error", err)
+			languagesOK = false
+		}
+	}
+	if !languagesOK {
+		os.Exit(1)
+	}
+
+	// 3. Запуск воркеров. Устаревший профиль селекторов сломает все проекты,
+	// поэтому, если он не подошел ни к одной странице, запуск останавливается
+	runCtx, stopRun := context.WithCancelCause(ctx)
+	defer stopRun(nil)
 	var wg sync.WaitGroup
 	sem := make(chan struct{}, config.MaxConcurrency)
 	tgBot := newTgBot(config.TgBotToken)
-
-	for _, url := range projects {
+	sess := newSession(browser, config, tgBot)
+	var done, failed, interrupted atomic.Int32
+	started := 0
+
+	for _, project := range projects {
+		// Захват слота; после сигнала новые проекты не начинаем
+		select {
+		case sem <- struct{}{}:
+		case <-runCtx.Done():
+		}
+		if runCtx.Err() != nil {
+			break
+		}
 		wg.Add(1)
-		sem <- struct{}{} // Захват слота
+		started++
 
-		go func(projectURL string) {
+		go func(project Project) {
 			defer wg.Done()
 			defer func() { <-sem }()
 
-			slog.Info("🚀 Старт обработки", "url", projectURL)
-			filename, err := processProject(browser, projectURL, config)
+			projectURL := project.URL
+			slog.Info("🚀 Старт обработки", "url", projectURL, "priority", project.Priority)
 
+			// Настройки проекта из манифеста поверх общих
+			projectConfig, err := project.apply(config)
+			if err != nil {
+				slog.Error("❌ Ошибка настроек проекта", "url", projectURL, "error", err)
+				notifyTelegram(config, tgBot, fmt.Sprintf("❌ Ошибка настроек проекта:\n%s\n%v", projectURL, err))
+				failed.Add(1)
+				return
+			}
+
+			// При истекшей сессии воркеры ждут повторного входа, и проект продолжается
+			filename, err := sess.process(projectURL, func() (string, error) {
+				return processProject(runCtx, browser, projectURL, projectConfig)
+			})
+
+			// Остановка по сигналу — не ошибка: состояние сохранено, проект остается в списке
+			if errors.Is(err, context.Canceled) {
+				slog.Warn("⏸️ Прервано, прогресс сохранен", "file", filename, "url", projectURL)
+				interrupted.Add(1)
+				return
+			}
+			var se *selectorMismatchError
+			if errors.As(err, &se) {
+				logSelectorReport(err)
+				failed.Add(1)
+				// Колонки языка нет в проекте, таблица пустая или не успела загрузиться —
+				// падает только этот проект
+				if !se.ProfileBroken {
+					messageText := fmt.Sprintf("🧩 Селекторы профиля %q не нашли элементы на странице проекта. Подробности в логе:\n<a href=\"%s\">%s</a>", se.Profile, projectURL, filename)
+					notifyTelegramError(config, tgBot, messageText, err)
+					return
+				}
+				messageText := fmt.Sprintf("🧩 Профиль селекторов %q не подходит к странице, запуск остановлен. Подробности в логе:\n<a href=\"%s\">%s</a>", se.Profile, projectURL, filename)
+				notifyTelegramError(config, tgBot, messageText, err)
+				stopRun(err)
+				return
+			}
+			var fe *incompleteFillError
+			if errors.As(err, &fe) {
+				slog.Error("⚠️ Вставлены не все строки", "file", filename, "url", projectURL, "error", err)
+				title := "⚠️ Вставлено"
+				if fe.Err != nil {
+					title = "⚠️ Вставка прервана, вставлено"
+				}
+				messageText := fmt.Sprintf("%s %d из %d:\n<a href=\"%s\">%s</a>\n%s", title,
+					fe.Result.Inserted, fe.Result.Total, projectURL, filename, html.EscapeString(shortKeyList(append(fe.failedKeys(), fe.mismatchedKeys()...))))
+				notifyTelegramError(config, tgBot, messageText, err)
+				failed.Add(1)
+				return
+			}
+			var te *timeoutError
+			if errors.As(err, &te) {
+				slog.Error("⏱️ Таймаут", "file", filename, "url", projectURL, "phase", te.Phase, "timeout", te.Timeout)
+				messageText := fmt.Sprintf("⏱️ Таймаут (%s, %s):\n<a href=\"%s\">%s</a>", te.Phase, te.Timeout, projectURL, filename)
+				notifyTelegramError(config, tgBot, messageText, err)
+				failed.Add(1)
+				return
+			}
 			if err != nil {
 				slog.Error("❌ Ошибка обработки", "file", filename, "url", projectURL, "error", err)
 				messageText := fmt.Sprintf("❌ Ошибка обработки:\n<a href=\"%s\">%s</a>", projectURL, filename)
+				notifyTelegramError(config, tgBot, messageText, err)
+				failed.Add(1)
+				return
+			}
+			done.Add(1)
+
+			if config.DryRun {
+				slog.Info("🔎 Dry-run завершен", "url", projectURL)
+				messageText := fmt.Sprintf("🔎 Dry-run, отчет готов:\n<a href=\"%s\">%s</a>", projectURL, filename)
 				notifyTelegram(config, tgBot, messageText)
 				return
 			}
@@ -235,10 +536,22 @@ func main() {
 			slog.Info("✅ Завершено", "url", projectURL)
 			messageText := fmt.Sprintf("✅ Завершено:\n<a href=\"%s\">%s</a>", projectURL, filename)
 			notifyTelegram(config, tgBot, messageText)
-		}(url)
+		}(project)
 	}
 
 	wg.Wait()
+
+	if runCtx.Err() != nil {
+		reason := "🛑 Остановлено по сигналу"
+		if ctx.Err() == nil {
+			reason = "🛑 Остановлено: профиль селекторов не подходит к странице"
+		}
+		skipped := len(projects) - started
+		slog.Warn(reason, "done", done.Load(), "failed", failed.Load(), "interrupted", interrupted.Load(), "not_started", skipped)
+		notifyTelegram(config, tgBot, fmt.Sprintf("%s\nЗавершено: %d\nС ошибкой: %d\nПрервано: %d\nНе начато: %d",
+			reason, done.Load(), failed.Load(), interrupted.Load(), skipped))
+		return
+	}
 	slog.Info("🏁 Все проекты обработаны!")
 }
 
@@ -248,6 +561,10 @@ func removeURLFromFile(filePath string, urlToRemove string) error {
 	fileMutex.Lock()         // Блокируем доступ для других потоков
 	defer fileMutex.Unlock() // Разблокируем в конце
 
+	if isManifest(filePath) {
+		return removeFromManifest(filePath, urlToRemove)
+	}
+
 	// 1. Читаем все текущие строки
 	data, err := os.ReadFile(filePath)
 	if err != nil {
@@ -276,7 +593,7 @@ func notifyTelegram(config Config, tgBot *telebot.Bot, messageText string) {
 		return
 	}
 
-	_, _ = tgBot.Send(
+	_, err = tgBot.Send(
 		telebot.ChatID(chatIdInt64),
 		messageText,
 		&telebot.SendOptions{
@@ -284,6 +601,42 @@ func notifyTelegram(config Config, tgBot *telebot.Bot, messageText string) {
 			DisableWebPagePreview: true, // Убирает большое окно с превью сайта
 		},
 	)
+	if err != nil {
+		slog.Warn("⚠️ Не удалось отправить сообщение в Telegram", "error", err)
+	}
+}
+
+// Сколько ключей перечислять в сообщении Telegram: подпись к фото ограничена
+// 1024 символами, сообщение — 4096. Полный список есть в логе
+const telegramMaxKeys = 20
+
+// shortKeyList перечисляет первые telegramMaxKeys ключей и сколько осталось.
+func shortKeyList(keys []string) string {
+	if len(keys) <= telegramMaxKeys {
+		return strings.Join(keys, ", ")
+	}
+	return fmt.Sprintf("%s и еще %d", strings.Join(keys[:telegramMaxKeys], ", "), len(keys)-telegramMaxKeys)
+}
+
+// notifyTelegramError отправляет сообщение об ошибке проекта; если при сбое
+// сохранился снимок экрана — прикладывает его к сообщению.
+func notifyTelegramError(config Config, tgBot *telebot.Bot, messageText string, err error) {
+	var de *diagnosticsError
+	if !errors.As(err, &de) || de.screenshot() == "" {
+		notifyTelegram(config, tgBot, messageText)
+		return
+	}
+
+	chatIdInt64, convErr := strconv.ParseInt(config.ChatId, 10, 64)
+	if convErr != nil {
+		slog.Error("Ошибка конвертации телеграм ChatId", "error", convErr)
+		return
+	}
+	photo := &telebot.Photo{File: telebot.FromDisk(de.screenshot()), Caption: messageText}
+	if _, sendErr := tgBot.Send(telebot.ChatID(chatIdInt64), photo, &telebot.SendOptions{ParseMode: telebot.ModeHTML}); sendErr != nil {
+		slog.Warn("⚠️ Не удалось отправить снимок экрана", "error", sendErr)
+		notifyTelegram(config, tgBot, messageText)
+	}
 }
 
 // ensureLogin проверяет наличие файла куки. Если нет - просит залогиниться и сохраняет.
@@ -294,6 +647,17 @@ func ensureLogin(browser playwright.Browser, config Config) error {
 	}
 
 	slog.Warn("⚠️ Файл авторизации не найден. Требуется вход.")
+	return login(browser, config)
+}
+
+// login открывает страницу входа, входит (сам по LOKALISE_EMAIL/LOKALISE_PASSWORD
+// или ждет, пока залогинится пользователь) и сохраняет куки.
+func login(browser playwright.Browser, config Config) error {
+	scripted := config.LoginEmail != "" && config.LoginPassword != ""
+	if config.Headless && !scripted {
+		return errors.New("headless mode requires LOKALISE_EMAIL and LOKALISE_PASSWORD")
+	}
+
 	context, err := browser.NewContext()
 	if err != nil {
 		return err
@@ -310,14 +674,21 @@ func ensureLogin(browser playwright.Browser, config Config) error {
 		return err
 	}
 
-	err = byId(page, "onetrust-accept-btn-handler").Click()
+	// Баннер куки есть не всегда — долго его не ждем
+	err = page.Locator(config.Selectors.Login.CookieAccept).Click(playwright.LocatorClickOptions{Timeout: playwright.Float(5000)})
 	if err != nil {
 		// panic("could not close accwpt cookies: " + err.Error())
 		slog.Warn("could not close accwpt cookies", "error", err)
 	}
 
-	fmt.Println("⌨️  Пожалуйста, залогиньтесь в браузере. После успешного входа нажмите ENTER в этой консоли...")
-	fmt.Scanln()
+	if scripted {
+		if err := scriptedLogin(page, config); err != nil {
+			return err
+		}
+	} else {
+		fmt.Println("⌨️  Пожалуйста, залогиньтесь в браузере. После успешного входа нажмите ENTER в этой консоли...")
+		fmt.Scanln()
+	}
 
 	// Сохраняем состояние (куки, local storage)
 	if _, err := context.StorageState(config.AuthStateFile); err != nil {
@@ -327,81 +698,235 @@ func ensureLogin(browser playwright.Browser, config Config) error {
 	return nil
 }
 
-func byId(page playwright.Page, id string) playwright.Locator {
-	selector := fmt.Sprintf("[id='%s']", id)
-	return page.Locator(selector)
+// isStopSignal — ctx из signal.NotifyContext отменен пришедшим сигналом, а не вызовом stop.
+// Причина отмены по сигналу проходит errors.Is(err, context.Canceled), поэтому сравниваем
+// с самим context.Canceled: его причиной ставит только stop.
+func isStopSignal(ctx context.Context) bool {
+	return ctx.Err() != nil && context.Cause(ctx) != context.Canceled
 }
 
-func readProjects(path string) ([]string, error) {
+// sleepContext — time.Sleep, прерываемый отменой ctx.
+func sleepContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// readProjects читает список проектов: YAML/JSON-манифест или простой
+// текстовый файл с одной ссылкой на строку.
+func readProjects(path string) ([]Project, error) {
+	if isManifest(path) {
+		return readManifest(path)
+	}
+
 	file, err := os.Open(path)
 	if err != nil {
 		return nil, err
 	}
 	defer file.Close()
 
-	var lines []string
+	var projects []Project
 	scanner := bufio.NewScanner(file)
 	for scanner.Scan() {
 		line := strings.TrimSpace(scanner.Text())
 		if line != "" && !strings.HasPrefix(line, "#") {
-			lines = append(lines, line)
+			projects = append(projects, Project{URL: line})
 		}
 	}
-	return lines, scanner.Err()
+	return projects, scanner.Err()
 }
 
-func processProject(browser playwright.Browser, projectURL string, config Config) (string, error) {
-	// Создаем контекст с сохраненными куками
-	context, err := browser.NewContext(playwright.BrowserNewContextOptions{
-		StorageStatePath: playwright.String(config.AuthStateFile),
-	})
+func processProject(ctx context.Context, browser playwright.Browser, projectURL string, config Config) (filename string, err error) {
+	store := newStateStore(config.StateDir)
+	state, err := store.Load(projectURL)
 	if err != nil {
-		return "", fmt.Errorf("could not create context: %v", err)
+		return "", fmt.Errorf("could not load job state: %v", err)
 	}
-	defer context.Close()
 
-	page, err := context.NewPage()
+	editor, err := newEditor(browser, config)
 	if err != nil {
-		return "", fmt.Errorf("could not create page: %v", err)
+		return state.Filename, err
 	}
-
-	if _, err = page.Goto(projectURL); err != nil {
-		return "", fmt.Errorf("could not goto url: %v", err)
+	defer editor.Close()
+
+	// Свой буфер последних строк лога для диагностики: у соседних воркеров свои строки
+	ctx, projectLog := withProjectLog(ctx, config.DiagLogLines)
+
+	// Общий дедлайн проекта и дедлайны фаз: по истечении watchdog сохраняет
+	// диагностику и закрывает редактор, чтобы зависший воркер освободил слот
+	ctx, cancel := withPhaseTimeout(ctx, "project", config.ProjectTimeout)
+	defer cancel()
+	wd := newWatchdog(editor, projectURL, projectLog, config)
+	defer wd.watch(ctx)()
+	// При сбое сохраняем диагностику, пока страница еще открыта. Остановка по сигналу — не сбой
+	defer func() {
+		if err == nil || errors.Is(err, context.Canceled) {
+			return
+		}
+		if dir := wd.diagnose(); dir != "" {
+			err = &diagnosticsError{Err: err, Dir: dir}
+		}
+	}()
+	phase := func(name string, timeout time.Duration) (context.Context, func()) {
+		phaseCtx, cancel := withPhaseTimeout(ctx, name, timeout)
+		stop := wd.watch(phaseCtx)
+		return phaseCtx, func() { stop(); cancel() }
 	}
 
-	filename, err := page.Locator("button[id='1'] strong").InnerText()
+	filename, err = editor.Open(ctx, projectURL)
 	if err != nil {
-		return "", fmt.Errorf("could not get filename: %v", err)
+		if te := timeoutCause(ctx); te != nil {
+			return filename, te
+		}
+		return filename, err
+	}
+	state.Filename = filename
+
+	// 1. Сбор пустых строк (пропускаем, если уже собраны до падения)
+	if !state.Collected {
+		collectCtx, done := phase("collect", config.CollectTimeout)
+		items, err := editor.Collect(collectCtx)
+		done()
+		if te := timeoutCause(collectCtx); te != nil {
+			return filename, te
+		}
+		if err != nil {
+			return filename, fmt.Errorf("scroll error: %w", err)
+		}
+		state.Items = items
+		state.Collected = true
+		if err := store.Save(state); err != nil {
+			return filename, fmt.Errorf("could not save job state: %v", err)
+		}
+	} else {
+		slog.InfoContext(ctx, "♻️ Продолжаем с сохраненного состояния", "file", filename, "items", len(state.Items), "inserted", len(state.Inserted))
+	}
+	if len(state.Items) == 0 {
+		if err := store.Delete(projectURL); err != nil {
+			return filename, err
+		}
+		// Импорт, в котором все строки файла отклонены QA: вставлять нечего, но проект не готов
+		if len(state.Rejected) > 0 {
+			return filename, untranslatedError(rejectedIDs(state.Rejected), len(state.Rejected))
+		}
+		slog.InfoContext(ctx, "ℹ️ Пустых строк не найдено", "url", projectURL)
+		return filename, nil
 	}
-	// Очистка имени файла от неразрывных пробелов и лишних символов
-	filename = strings.TrimSpace(strings.ReplaceAll(filename, "\u00a0", " "))
-	filename = strings.TrimPrefix(filename, "Filename: ")
-	filename = strings.TrimSpace(filename)
 
-	// 1. Сбор пустых строк
-	translationMap, err := scrollAndCollect(page, config, filename)
-	if err != nil {
-		return filename, fmt.Errorf("scroll error: %v", err)
+	// 2. Перевод (Gemini или другой движок из TRANSLATOR) — отдельно для каждого языка
+	if !state.Translated {
+		translateCtx, done := phase("translate", config.TranslateTimeout)
+		err := translateState(translateCtx, state, config)
+		done()
+		if te := timeoutCause(translateCtx); te != nil {
+			return filename, te
+		}
+		if err != nil {
+			return filename, err
+		}
+		if err := store.Save(state); err != nil {
+			return filename, fmt.Errorf("could not save job state: %v", err)
+		}
 	}
-	if len(translationMap) == 0 {
-		slog.Info("ℹ️ Пустых строк не найдено", "url", projectURL)
+
+	// В режиме dry-run только сохраняем отчет, редактор не трогаем
+	if config.DryRun {
+		path, err := writeReport(config, projectURL, filename, append(state.Translations, state.Rejected...))
+		if err != nil {
+			return filename, fmt.Errorf("could not write report: %v", err)
+		}
+		slog.InfoContext(ctx, "🔎 Dry-run: отчет сохранен", "file", filename, "report", path,
+			"translated", len(state.Translations), "rejected", len(state.Rejected), "missing", len(state.GapIDs))
 		return filename, nil
 	}
 
-	// 2. Перевод через Gemini
-	translatedItems, err := translateWithGemini(translationMap, config)
-	//translatedItems, err := mockTranslateWithGemini(translationMap, config)
+	// 4. Вставка переводов (только еще не сохраненных)
+	pending := state.pending()
+	fillTimeout := config.FillTimeout
+	if fillTimeout <= 0 {
+		// Без общего лимита вставка ограничена по числу строк: зависшую страницу
+		// все равно нужно закрыть, а большой проект не должен упираться в фиксированный срок
+		fillTimeout = time.Duration(len(pending)) * config.FillRowTimeout
+	}
+	fillCtx, done := phase("fill", fillTimeout)
+	result, err := editor.Fill(fillCtx, pending, stateJournal{ctx: ctx, store: store, state: state})
+	done()
+	if err != nil && fillCtx.Err() != nil {
+		// Журнал вставок уже на диске; снимок обновляем, чтобы продолжить с этого места
+		if err := store.Save(state); err != nil {
+			slog.WarnContext(ctx, "⚠️ Не удалось сохранить состояние", "url", projectURL, "error", err)
+		}
+		slog.InfoContext(ctx, "💾 Состояние сохранено", "file", filename, "inserted", len(state.Inserted), "total", len(state.Translations))
+		if te := timeoutCause(fillCtx); te != nil {
+			return filename, te
+		}
+	}
+	slog.InfoContext(ctx, "📊 Вставка завершена", "file", filename, "inserted", result.Inserted, "total", result.Total,
+		"skipped", len(result.Skipped), "failed", len(result.Failed), "mismatched", len(result.Mismatched))
 	if err != nil {
-		return filename, fmt.Errorf("gemini error: %v", err)
+		// Вставка оборвалась на середине (подряд падают строки) — итог нужен именно здесь.
+		// Остановку по сигналу и истекшую сессию обрабатывает вызывающий код
+		if fillCtx.Err() != nil || errors.Is(err, errSessionExpired) {
+			return filename, err
+		}
+		return filename, &incompleteFillError{Result: result, Err: err}
 	}
+	// Пропущенные строки и строки с неверным текстом не отмечены сохраненными:
+	// состояние остается, и следующий запуск вставит только их
+	if len(result.Failed) > 0 || len(result.Mismatched) > 0 {
+		return filename, &incompleteFillError{Result: result}
+	}
+
+	// Все вставлено — состояние больше не нужно. Недостающие строки
+	// соберутся заново следующим запуском, проект для этого остается в списке.
+	if err := store.Delete(projectURL); err != nil {
+		slog.WarnContext(ctx, "⚠️ Не удалось удалить состояние", "url", projectURL, "error", err)
+	}
+	if gapIDs := append(state.GapIDs, rejectedIDs(state.Rejected)...); len(gapIDs) > 0 {
+		return filename, untranslatedError(gapIDs, len(state.Items))
+	}
+	return filename, nil
+}
+
+// translateState переводит собранные строки отдельно для каждого языка и проверяет их QA.
+func translateState(ctx context.Context, state *jobState, config Config) error {
+	state.Translations, state.Rejected, state.GapIDs = nil, nil, nil
+	for _, group := range groupByLang(state.Items) {
+		langConfig := config.forLanguage(group[0].LangID)
+		translator, err := newTranslator(langConfig)
+		if err != nil {
+			return err
+		}
+		slog.InfoContext(ctx, "🌐 Перевод языка", "lang_id", group[0].LangID, "items", len(group))
 
-	// 3. Вставка переводов
-	err = fillTranslations(page, translatedItems, config)
+		translatedItems, gapIDs, err := translateAndReconcile(ctx, translator, group, langConfig)
+		if err != nil {
+			return fmt.Errorf("%s error: %w", langConfig.Translator, err)
+		}
 
-	return filename, err
+		// 3. Проверка плейсхолдеров и разметки — битые строки в редактор не пишем
+		translatedItems, rejected := qaPass(ctx, translator, translatedItems, langConfig)
+		// Прерванный повтор QA отклонил бы строки зря — такой перевод не сохраняем
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		state.Translations = append(state.Translations, translatedItems...)
+		state.Rejected = append(state.Rejected, rejected...)
+		for _, id := range gapIDs {
+			state.GapIDs = append(state.GapIDs, group[0].LangID+":"+id)
+		}
+	}
+	state.Translated = true
+	return nil
 }
 
-func scrollAndCollect(page playwright.Page, config Config, filename string) ([]TranslationItem, error) {
+func scrollAndCollect(ctx context.Context, page playwright.Page, config Config, filename string) ([]TranslationItem, error) {
 	var results []TranslationItem
 	seen := make(map[string]bool)
 
@@ -409,19 +934,20 @@ func scrollAndCollect(page playwright.Page, config Config, filename string) ([]T
 	maxNoNewRetries := 5
 	totalScrolled := 0.0
 
-	slog.Info("🔍 Начинаю поиск пустых строк", "file", filename)
+	slog.InfoContext(ctx, "🔍 Начинаю поиск пустых строк", "file", filename)
+	sel := config.Selectors.Editor
 
 	for noNewElementsCount < maxNoNewRetries {
 		newAddedThisStep := 0
 		foundEmptyInThisStep := 0
 
-		rows, err := page.Locator(".row-key[data-id]").All()
+		rows, err := page.Locator(sel.Row).All()
 		if err != nil {
 			break
 		}
 
 		for _, row := range rows {
-			id, _ := row.GetAttribute("data-id")
+			id, _ := row.GetAttribute(sel.RowIDAttr)
 			if id == "" || seen[id] {
 				continue
 			}
@@ -430,22 +956,26 @@ func scrollAndCollect(page playwright.Page, config Config, filename string) ([]T
 			seen[id] = true
 			newAddedThisStep++
 
-			// Проверка на пустоту
-			targetCell := row.Locator(fmt.Sprintf(".cell-trans[data-lang-id='%s']", config.TargetLangID))
-			isEmpty, _ := targetCell.Locator(".empty").Count()
-			cellText, _ := targetCell.InnerText()
-
-			if isEmpty > 0 || strings.TrimSpace(cellText) == "" || strings.TrimSpace(cellText) == "Empty" {
-				originalText, err := row.Locator(".base-cell-trans .highlight").First().InnerText()
-				if err != nil || originalText == "" {
-					originalText, _ = row.Locator(".base-cell-trans").InnerText()
+			// Проверка на пустоту — по всем целевым языкам за один проход
+			originalText := ""
+			for _, langID := range config.TargetLangIDs {
+				targetCell := row.Locator(sel.targetCell(langID))
+				if isEmptyCell(targetCell, sel) {
+					if originalText == "" {
+						var err error
+						originalText, err = row.Locator(sel.SourceText).First().InnerText()
+						if err != nil || originalText == "" {
+							originalText, _ = row.Locator(sel.SourceCell).InnerText()
+						}
+					}
+
+					results = append(results, TranslationItem{
+						ID:       id,
+						LangID:   langID,
+						Original: strings.TrimSpace(originalText),
+					})
+					foundEmptyInThisStep++
 				}
-
-				results = append(results, TranslationItem{
-					ID:       id,
-					Original: strings.TrimSpace(originalText),
-				})
-				foundEmptyInThisStep++
 			}
 		}
 
@@ -458,172 +988,206 @@ func scrollAndCollect(page playwright.Page, config Config, filename string) ([]T
 		scrollStep := 800.0
 		page.Mouse().Wheel(0, scrollStep)
 		totalScrolled += scrollStep
-		time.Sleep(config.ScrollDelay)
+		// Неполный сбор не сохраняем: после перезапуска проект соберется заново
+		if err := sleepContext(ctx, config.ScrollDelay); err != nil {
+			return nil, err
+		}
 	}
 
 	// Возвращаем курсор в начало
 	_ = page.Mouse().Wheel(0, -totalScrolled)
 
 	// КРАСИВЫЙ ФИНАЛЬНЫЙ ВЫВОД
-	slog.Info("✅ Сбор данных завершен", "file", filename, "checked", len(seen), "collected", len(results))
+	slog.InfoContext(ctx, "✅ Сбор данных завершен", "file", filename, "checked", len(seen), "collected", len(results))
 
 	return results, nil
 }
 
-func mockTranslateWithGemini(tmap []TranslationItem, config Config) ([]TranslationItem, error) {
-	return []TranslationItem{
-		{ID: "798330850", Translation: "mock polish translation"},
-	}, nil
+// isEmptyCell — в ячейке нет перевода: заглушка Empty или пустой текст.
+func isEmptyCell(cell playwright.Locator, sel EditorSelectors) bool {
+	marker, _ := cell.Locator(sel.EmptyMarker).Count()
+	text, _ := cell.InnerText()
+	return marker > 0 || strings.TrimSpace(text) == "" || strings.TrimSpace(text) == sel.EmptyText
 }
 
-func translateWithGemini(tmap []TranslationItem, config Config) ([]TranslationItem, error) {
-	slog.Info("⏳ Запрос к Gemini...")
-
-	var payloadItems []TranslationItem
-	for _, v := range tmap {
-		payloadItems = append(payloadItems, v)
-	}
-
-	// ВАШ ОРИГИНАЛЬНЫЙ ПРОМПТ
-	prompt := fmt.Sprintf(`%s
-
-IMPORTANT: Respond ONLY with a valid JSON object. 
-Do NOT repeat the translation twice in the output string.
-Structure: {"results": [{"id": "ID_HERE", "translation": "POLISH_TEXT_HERE"}, ...]}
-
-Data to translate: %s`, config.Prompt, func() string { b, _ := json.Marshal(payloadItems); return string(b) }())
+// Столько строк подряд с ошибкой означает, что сломалась сама страница (модальное окно,
+// потерянная сессия), и пропускать остальные строки по одной бессмысленно
+const maxConsecutiveRowFailures = 5
+
+// fillTranslations вставляет переводы по одному и отмечает каждую сохраненную строку в journal.
+// Строка, которая не вставилась и после ROW_RETRIES повторов, пропускается и попадает в fillResult.
+// Отмена ctx проверяется только между строками, чтобы не оставить ячейку недописанной.
+func fillTranslations(ctx context.Context, page playwright.Page, items []TranslationItem, config Config, journal fillJournal) (fillResult, error) {
+	slog.InfoContext(ctx, "✍️ Вставка переводов...", "strategy", config.Selectors.Editor.InsertStrategy)
+	result := fillResult{Total: len(items)}
+	consecutiveFailures := 0
+
+	// Скорость вставки в логе — по ней сравниваются способы ввода (insert_strategy)
+	start, rows, chars := time.Now(), 0, 0
+	defer func() {
+		if rows == 0 {
+			return
+		}
+		elapsed := time.Since(start)
+		slog.InfoContext(ctx, "⏱️ Скорость вставки", "strategy", config.Selectors.Editor.InsertStrategy, "rows", rows, "chars", chars,
+			"elapsed", elapsed.Round(time.Second), "rows_per_min", fmt.Sprintf("%.1f", float64(rows)/elapsed.Minutes()),
+			"sec_per_row", fmt.Sprintf("%.2f", elapsed.Seconds()/float64(rows)))
+	}()
+
+	for i, item := range items {
+		if err := ctx.Err(); err != nil {
+			return result, err
+		}
 
-	geminiReq := GeminiPayload{}
-	geminiReq.Contents = append(geminiReq.Contents, struct {
-		Parts []struct {
-			Text string `json:"text"`
-		} `json:"parts"`
-	}{})
-	geminiReq.Contents[0].Parts = append(geminiReq.Contents[0].Parts, struct {
-		Text string `json:"text"`
-	}{Text: prompt})
+		// fmt.Printf("[%d/%d] ID: %s | Вставка...\n", i+1, len(items), item.ID)
 
-	jsonPayload, _ := json.Marshal(geminiReq)
-	url := fmt.Sprintf("https://generativelanguage.googleapis.com/v1/models/%s:generateContent?key=%s", config.Model, config.GeminiAPIKey)
+		mismatch, err := fillRow(ctx, page, item, config, journal)
+		for retry := 1; err != nil && !errors.Is(err, errCellFilled) && retry <= config.RowRetries; retry++ {
+			slog.WarnContext(ctx, "🔁 Ошибка вставки строки, повторяем", "id", item.ID, "lang_id", item.LangID, "attempt", retry, "error", err)
+			// Закрываем редактор, если он остался открытым
+			_ = page.Keyboard().Press("Escape")
+			// Остановка во время паузы: строка не сохранена, повторит следующий запуск
+			if stopErr := sleepContext(ctx, config.RowNextDelay); stopErr != nil {
+				return result, stopErr
+			}
+			mismatch, err = fillRow(ctx, page, item, config, journal)
+		}
+		if errors.Is(err, errCellFilled) {
+			slog.WarnContext(ctx, "✋ В ячейке уже есть текст, строка пропущена", "id", item.ID, "lang_id", item.LangID, "row", i+1)
+			result.Skipped = append(result.Skipped, item)
+			consecutiveFailures = 0
+			continue
+		}
+		rows++
+		chars += utf8.RuneCountInString(item.Translation)
+
+		switch {
+		case err != nil:
+			slog.ErrorContext(ctx, "⏭️ Строка пропущена", "id", item.ID, "lang_id", item.LangID, "row", i+1, "error", err)
+			result.Failed = append(result.Failed, rowFailure{Item: item, Err: err.Error()})
+			_ = page.Keyboard().Press("Escape")
+			consecutiveFailures++
+			// Ушли на страницу входа или все валится подряд — дальше пробовать бессмысленно
+			if isSignInURL(page.URL()) || consecutiveFailures >= maxConsecutiveRowFailures {
+				return result, fmt.Errorf("aborted after %d consecutive failed rows: %v", consecutiveFailures, err)
+			}
+		case mismatch != nil:
+			result.Mismatched = append(result.Mismatched, *mismatch)
+			consecutiveFailures = 0
+		default:
+			journal.MarkSaved(item)
+			result.Inserted++
+			consecutiveFailures = 0
+		}
 
-	resp, err := http.Post(url, "application/json", bytes.NewBuffer(jsonPayload))
-	if err != nil {
-		return nil, err
+		if err := sleepContext(ctx, config.RowNextDelay); err != nil {
+			return result, err
+		}
 	}
-	defer resp.Body.Close()
+	return result, nil
+}
 
-	body, _ := io.ReadAll(resp.Body)
+// errCellFilled — ячейка уже не пуста, и текст в ней вставляли не мы.
+var errCellFilled = errors.New("target cell is not empty")
 
-	// --- ВЫВОД RAW ОТВЕТА В КОНСОЛЬ ---
-	// fmt.Printf("\n[RAW LLM RESPONSE]:\n%s\n\n", string(body))
+// fillRow вставляет одну строку и проверяет сохраненный текст, перезаписывая ячейку
+// до VERIFY_RETRIES раз. Ошибка означает, что вставить не удалось вовсе;
+// errCellFilled — что ячейку заполнили после сбора и строку нужно пропустить.
+func fillRow(ctx context.Context, page playwright.Page, item TranslationItem, config Config, journal fillJournal) (*rowMismatch, error) {
+	sel := config.Selectors.Editor
+	row := page.Locator(sel.rowByID(item.ID))
+	cell := row.Locator(sel.targetCell(item.LangID))
 
-	// Извлекаем JSON из ответа (убираем возможные Markdown обертки)
-	respStr := string(body)
-	start := strings.Index(respStr, "{")
-	end := strings.LastIndex(respStr, "}")
-	if start == -1 || end == -1 {
-		return nil, fmt.Errorf("invalid response format")
+	// Пустоту проверяем на видимой строке: таблица рендерит только строки в окне
+	if err := row.ScrollIntoViewIfNeeded(); err != nil {
+		return nil, errors.New("could not scroll to row: " + err.Error())
 	}
-
-	// Парсим структуру Gemini Candidate
-	var rawMap map[string]interface{}
-	json.Unmarshal(body, &rawMap)
-
-	// В Go структура Gemini вложена: candidates[0].content.parts[0].text
-	// Для простоты примера вытащим текст через простое сопоставление или доп. структуру
-	candidates, ok := rawMap["candidates"].([]interface{})
-	if !ok || len(candidates) == 0 {
-		return nil, fmt.Errorf("no candidates in response: %s", string(body))
+	// Текст в ячейке мог появиться после сбора (перевел человек, отчет dry-run
+	// устарел) — перезаписываем только строки, которые по журналу начинали вставлять мы
+	filled := !isEmptyCell(cell, sel)
+	if !journal.Attempted(item) {
+		if filled {
+			return nil, errCellFilled
+		}
+		journal.MarkAttempted(item)
 	}
-	candidate := candidates[0].(map[string]interface{})
-	content := candidate["content"].(map[string]interface{})
-	parts := content["parts"].([]interface{})
-	actualJSON := parts[0].(map[string]interface{})["text"].(string)
 
-	// Применяем очистку
-	cleanJSON := sanitizeJSON(actualJSON)
+	for attempt := 0; ; attempt++ {
+		// Повтор перезаписывает ячейку: в ней уже может быть неверный текст,
+		// в том числе от неудачной попытки прошлого запуска
+		emptyClick, _ := cell.Locator(sel.EmptyClick).Count()
+		overwrite := attempt > 0 || filled || emptyClick == 0
+		// Если быстрая вставка сохранила не тот текст, повтор набирает его посимвольно
+		strategy := sel.InsertStrategy
+		if attempt > 0 {
+			strategy = insertStrategyType
+		}
+		if err := insertRow(ctx, page, row, cell, item, config, overwrite, strategy); err != nil {
+			return nil, err
+		}
+		if !config.VerifySave {
+			return nil, nil
+		}
 
-	var finalResp GeminiResponse
-	err = json.Unmarshal([]byte(cleanJSON), &finalResp)
-	if err != nil {
-		// Выводим текст, который не удалось распарсить, для удобства дебага
-		return nil, fmt.Errorf("Не удалось распарсить ответ от gemini: %w \nТекст после очистки: %s", err, cleanJSON)
+		// Проверяем, что в ячейке именно то, что набирали
+		actual, ok := verifyCell(cell, item.Translation)
+		if ok {
+			return nil, nil
+		}
+		if attempt >= config.VerifyRetries {
+			slog.ErrorContext(ctx, "❌ Текст в ячейке не совпадает с переводом", "id", item.ID, "lang_id", item.LangID, "expected", item.Translation, "actual", actual)
+			return &rowMismatch{Item: item, Actual: actual}, nil
+		}
+		slog.WarnContext(ctx, "🔁 Текст в ячейке не совпал, вставляем заново", "id", item.ID, "lang_id", item.LangID, "attempt", attempt+1, "actual", actual)
 	}
-
-	return finalResp.Results, nil
 }
 
-func sanitizeJSON(input string) string {
-	// Убираем пробелы и переносы строк в начале и конце
-	input = strings.TrimSpace(input)
+// insertRow открывает редактор ячейки, набирает перевод и сохраняет.
+// При overwrite кликаем в саму ячейку и выделяем ее текст, а не ищем заглушку Empty.
+func insertRow(ctx context.Context, page playwright.Page, row, cell playwright.Locator, item TranslationItem, config Config, overwrite bool, strategy string) error {
+	sel := config.Selectors.Editor
 
-	// Если ответ обернут в блоки кода Markdown
-	if strings.HasPrefix(input, "```") {
-		// Убираем открывающий блок (поддерживаем ```json и просто ```)
-		input = strings.TrimPrefix(input, "```json")
-		input = strings.TrimPrefix(input, "```")
-
-		// Убираем закрывающий блок
-		input = strings.TrimSuffix(input, "```")
-
-		// Повторно чистим пробелы
-		input = strings.TrimSpace(input)
+	// Скроллим к строке
+	err := row.ScrollIntoViewIfNeeded()
+	if err != nil {
+		return errors.New("could not scroll to row: " + err.Error())
 	}
-
-	// На всякий случай: если перед JSON есть какой-то текст,
-	// находим первое вхождение { и последнее }
-	start := strings.Index(input, "{")
-	end := strings.LastIndex(input, "}")
-	if start != -1 && end != -1 && end > start {
-		input = input[start : end+1]
+	// Кликаем в ячейку нужного языка: в строке может быть несколько пустых колонок
+	if overwrite {
+		err = cell.Click()
+	} else {
+		err = cell.Locator(sel.EmptyClick).Click()
+	}
+	if err != nil {
+		return errors.New("could not click cell: " + err.Error())
 	}
 
-	return input
-}
-
-func fillTranslations(page playwright.Page, items []TranslationItem, config Config) error {
-	slog.Info("✍️ Вставка переводов...")
-	for _, item := range items {
-		// fmt.Printf("[%d/%d] ID: %s | Вставка...\n", i+1, len(items), item.ID)
-
-		selector := fmt.Sprintf(".row-key[data-id='%s']", item.ID)
-
-		// Скроллим к строке
-		row := page.Locator(selector)
-		err := row.ScrollIntoViewIfNeeded()
-		if err != nil {
-			return errors.New("could not scroll to row: " + err.Error())
-		}
-		err = row.Locator("text=Empty").Click()
-		if err != nil {
-			return errors.New("could not click cell: " + err.Error())
-		}
-
-		time.Sleep(config.EditorLoadDelay)
+	time.Sleep(config.EditorLoadDelay)
 
-		err = page.Keyboard().Type(item.Translation)
-		if err != nil {
-			return errors.New("could not type translation: " + err.Error())
+	if overwrite {
+		if err := page.Keyboard().Press("ControlOrMeta+A"); err != nil {
+			return errors.New("could not select cell text: " + err.Error())
 		}
+	}
+	if err := insertText(ctx, page, sel, strategy, item.Translation); err != nil {
+		return err
+	}
 
-		time.Sleep(config.BeforeSaveDelay)
+	time.Sleep(config.BeforeSaveDelay)
 
-		// Пытаемся нажать кнопку Save
-		saveBtn := page.Locator("button.save.btn-primary")
-		err = saveBtn.Click()
-		if err != nil {
-			return errors.New("could not click save btn: " + err.Error())
-		}
+	// Пытаемся нажать кнопку Save
+	saveBtn := page.Locator(sel.SaveButton)
+	err = saveBtn.Click()
+	if err != nil {
+		return errors.New("could not click save btn: " + err.Error())
+	}
 
-		editorSelector := ".ace_text-input, textarea:not([style*='display: none']), [contenteditable='true']"
-		// Ждем закрытия редактора
-		for j := 0; j < 10; j++ {
-			if visible, _ := page.IsVisible(editorSelector); !visible {
-				break
-			}
-			time.Sleep(200 * time.Millisecond)
+	// Ждем закрытия редактора
+	for j := 0; j < 10; j++ {
+		if visible, _ := page.IsVisible(sel.OpenedEditor); !visible {
+			break
 		}
-		time.Sleep(config.RowNextDelay)
+		time.Sleep(200 * time.Millisecond)
 	}
 	return nil
 }
@@ -0,0 +1,52 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"os"
+	"os/signal"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestIsStopSignal(t *testing.T) {
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGUSR1)
+	defer stop()
+	if isStopSignal(ctx) {
+		t.Fatal("isStopSignal before any signal")
+	}
+	syscall.Kill(os.Getpid(), syscall.SIGUSR1)
+	select {
+	case <-ctx.Done():
+	case <-time.After(5 * time.Second):
+		t.Fatal("signal was not delivered")
+	}
+	if !isStopSignal(ctx) {
+		t.Errorf("isStopSignal after a signal = false, cause %v", context.Cause(ctx))
+	}
+
+	// Обычное завершение программы: stop() из defer
+	ctx, stop = signal.NotifyContext(context.Background(), syscall.SIGUSR1)
+	stop()
+	<-ctx.Done()
+	if isStopSignal(ctx) {
+		t.Error("isStopSignal after stop() = true")
+	}
+}
+
+func TestSleepContext(t *testing.T) {
+	if err := sleepContext(context.Background(), time.Millisecond); err != nil {
+		t.Errorf("sleepContext = %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(10*time.Millisecond, cancel)
+	start := time.Now()
+	if err := sleepContext(ctx, time.Minute); !errors.Is(err, context.Canceled) {
+		t.Errorf("sleepContext = %v, want context.Canceled", err)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("sleepContext waited %v after cancel", elapsed)
+	}
+}
@@ -0,0 +1,232 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Project — один проект из списка. Пустые поля берутся из общей конфигурации (.env).
+type Project struct {
+	URL           string            `yaml:"url" json:"url"`
+	TargetLangIDs []string          `yaml:"target_lang_ids,omitempty" json:"target_lang_ids,omitempty"`
+	Translator    string            `yaml:"translator,omitempty" json:"translator,omitempty"`
+	Model         string            `yaml:"model,omitempty" json:"model,omitempty"`
+	PromptFile    string            `yaml:"prompt_file,omitempty" json:"prompt_file,omitempty"`
+	PromptFiles   map[string]string `yaml:"prompt_files,omitempty" json:"prompt_files,omitempty"` // lang_id -> файл промпта
+	Glossary      string            `yaml:"glossary,omitempty" json:"glossary,omitempty"`
+	Priority      int               `yaml:"priority,omitempty" json:"priority,omitempty"`
+	Delays        *ProjectDelays    `yaml:"delays,omitempty" json:"delays,omitempty"`
+}
+
+// ProjectDelays — задержки UI для проекта, в миллисекундах.
+type ProjectDelays struct {
+	ScrollMs     *int `yaml:"scroll_ms,omitempty" json:"scroll_ms,omitempty"`
+	EditorLoadMs *int `yaml:"editor_load_ms,omitempty" json:"editor_load_ms,omitempty"`
+	FocusMs      *int `yaml:"focus_ms,omitempty" json:"focus_ms,omitempty"`
+	BeforeSaveMs *int `yaml:"before_save_ms,omitempty" json:"before_save_ms,omitempty"`
+	RowNextMs    *int `yaml:"row_next_ms,omitempty" json:"row_next_ms,omitempty"`
+}
+
+type manifestFile struct {
+	Projects []Project `yaml:"projects" json:"projects"`
+}
+
+// isManifest — YAML/JSON-манифест вместо простого списка ссылок.
+func isManifest(path string) bool {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml", ".json":
+		return true
+	}
+	return false
+}
+
+// readManifest читает манифест. JSON — подмножество YAML, поэтому разбираем оба одним парсером.
+func readManifest(path string) ([]Project, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var manifest manifestFile
+	if err := yaml.Unmarshal(data, &manifest); err != nil {
+		return nil, fmt.Errorf("invalid manifest %s: %v", path, err)
+	}
+
+	var projects []Project
+	for i, p := range manifest.Projects {
+		p.URL = strings.TrimSpace(p.URL)
+		if p.URL == "" {
+			return nil, fmt.Errorf("invalid manifest %s: project #%d has no url", path, i+1)
+		}
+		projects = append(projects, p)
+	}
+
+	// Сначала проекты с большим приоритетом, при равном — в порядке файла
+	sort.SliceStable(projects, func(i, j int) bool {
+		return projects[i].Priority > projects[j].Priority
+	})
+	return projects, nil
+}
+
+// apply возвращает копию общей конфигурации с настройками проекта.
+func (p Project) apply(config Config) (Config, error) {
+	if len(p.TargetLangIDs) > 0 {
+		config.TargetLangIDs = p.TargetLangIDs
+	}
+	if p.Translator != "" {
+		config.Translator = p.Translator
+	}
+	if p.Model != "" {
+		config.Model = p.Model
+	}
+	if p.PromptFile != "" {
+		data, err := os.ReadFile(p.PromptFile)
+		if err != nil {
+			return config, fmt.Errorf("could not read prompt file: %v", err)
+		}
+		config.Prompt = string(data)
+		// Промпт проекта действует для всех его языков и важнее общих промптов
+		// из PROMPT_DIR; свой промпт языка в prompt_files важнее его
+		config.LangPrompts = make(map[string]string, len(config.TargetLangIDs))
+		for _, langID := range config.TargetLangIDs {
+			config.LangPrompts[langID] = config.Prompt
+		}
+	}
+	if len(p.PromptFiles) > 0 {
+		langPrompts := make(map[string]string, len(config.LangPrompts)+len(p.PromptFiles))
+		for langID, prompt := range config.LangPrompts {
+			langPrompts[langID] = prompt
+		}
+		for langID, file := range p.PromptFiles {
+			data, err := os.ReadFile(file)
+			if err != nil {
+				return config, fmt.Errorf("could not read prompt file for %s: %v", langID, err)
+			}
+			langPrompts[langID] = string(data)
+		}
+		config.LangPrompts = langPrompts
+	}
+	if p.Glossary != "" {
+		config.GlossaryID = p.Glossary
+	}
+
+	if p.Delays == nil {
+		return config, nil
+	}
+	delays := []struct {
+		ms     *int
+		target *time.Duration
+	}{
+		{p.Delays.ScrollMs, &config.ScrollDelay},
+		{p.Delays.EditorLoadMs, &config.EditorLoadDelay},
+		{p.Delays.FocusMs, &config.FocusDelay},
+		{p.Delays.BeforeSaveMs, &config.BeforeSaveDelay},
+		{p.Delays.RowNextMs, &config.RowNextDelay},
+	}
+	for _, d := range delays {
+		if d.ms != nil {
+			*d.target = time.Duration(*d.ms) * time.Millisecond
+		}
+	}
+	return config, nil
+}
+
+// removeFromManifest удаляет проект из манифеста. YAML правится через дерево
+// узлов, чтобы сохранить комментарии и порядок полей, JSON — без разбора в Project.
+func removeFromManifest(path string, urlToRemove string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	if strings.EqualFold(filepath.Ext(path), ".json") {
+		return removeFromJSONManifest(path, data, urlToRemove)
+	}
+
+	var root yaml.Node
+	if err := yaml.Unmarshal(data, &root); err != nil {
+		return err
+	}
+	if len(root.Content) == 0 || root.Content[0].Kind != yaml.MappingNode {
+		return fmt.Errorf("invalid manifest %s: expected a mapping with projects", path)
+	}
+	doc := root.Content[0]
+	for i := 0; i+1 < len(doc.Content); i += 2 {
+		if doc.Content[i].Value != "projects" || doc.Content[i+1].Kind != yaml.SequenceNode {
+			continue
+		}
+		seq := doc.Content[i+1]
+		var kept []*yaml.Node
+		for _, item := range seq.Content {
+			var p Project
+			if err := item.Decode(&p); err == nil && strings.TrimSpace(p.URL) == urlToRemove {
+				continue
+			}
+			kept = append(kept, item)
+		}
+		seq.Content = kept
+	}
+
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(&root); err != nil {
+		return err
+	}
+	if err := enc.Close(); err != nil {
+		return err
+	}
+	return os.WriteFile(path, buf.Bytes(), 0644)
+}
+
+// removeFromJSONManifest удаляет проект из JSON-манифеста. Проекты не разбираются
+// в Project: поля, которых программа не знает, остаются в файле как были.
+func removeFromJSONManifest(path string, data []byte, urlToRemove string) error {
+	var manifest map[string]json.RawMessage
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		return fmt.Errorf("invalid manifest %s: %v", path, err)
+	}
+	var projects []json.RawMessage
+	if err := json.Unmarshal(manifest["projects"], &projects); err != nil {
+		return fmt.Errorf("invalid manifest %s: %v", path, err)
+	}
+
+	kept := make([]json.RawMessage, 0, len(projects))
+	for _, raw := range projects {
+		var p struct {
+			URL string `json:"url"`
+		}
+		if err := json.Unmarshal(raw, &p); err == nil && strings.TrimSpace(p.URL) == urlToRemove {
+			continue
+		}
+		kept = append(kept, raw)
+	}
+	// Без экранирования HTML: & в ссылках проектов остается как есть
+	encode := func(v any) ([]byte, error) {
+		var buf bytes.Buffer
+		enc := json.NewEncoder(&buf)
+		enc.SetEscapeHTML(false)
+		enc.SetIndent("", "  ")
+		err := enc.Encode(v)
+		return buf.Bytes(), err
+	}
+	projectsJSON, err := encode(kept)
+	if err != nil {
+		return err
+	}
+	manifest["projects"] = projectsJSON
+
+	out, err := encode(manifest)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, out, 0644)
+}
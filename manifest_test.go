@@ -0,0 +1,270 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+// Пример из репозитория должен проходить проверку языков: иначе запуск
+// с ним прерывается еще до первого проекта.
+func TestProjectsExamplePassesLanguageCheck(t *testing.T) {
+	example, err := filepath.Abs("projects.example.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Chdir(t.TempDir())
+	os.Mkdir("prompts", 0755)
+	for _, name := range []string{"coaching.txt", "coaching-cs.txt"} {
+		os.WriteFile(filepath.Join("prompts", name), []byte("prompt "+name), 0644)
+	}
+
+	projects, err := readManifest(example)
+	if err != nil {
+		t.Fatalf("readManifest: %v", err)
+	}
+	base := Config{Translator: "gemini", TargetLangIDs: []string{"748"}, DeepLTargetLang: "PL", DeepLSourceLang: "EN"}
+	for _, project := range projects {
+		config, err := project.apply(base)
+		if err != nil {
+			t.Fatalf("apply %s: %v", project.URL, err)
+		}
+		if err := config.checkLanguages(); err != nil {
+			t.Errorf("checkLanguages %s: %v", project.URL, err)
+		}
+	}
+}
+
+func TestApplyPromptFiles(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name string) string {
+		path := filepath.Join(dir, name)
+		os.WriteFile(path, []byte(name), 0644)
+		return path
+	}
+	base := Config{
+		TargetLangIDs: []string{"748", "749"},
+		Prompt:        "prompt.txt",
+		LangPrompts:   map[string]string{"748": "prompts/748.txt", "750": "prompts/750.txt"},
+	}
+	tests := []struct {
+		name    string
+		project Project
+		want    map[string]string
+	}{
+		{"shared prompts", Project{}, map[string]string{"748": "prompts/748.txt", "750": "prompts/750.txt"}},
+		{"prompt_file for every language", Project{PromptFile: write("project.txt")}, map[string]string{"748": "project.txt", "749": "project.txt"}},
+		{"prompt_files over prompt_file", Project{PromptFile: write("project.txt"), PromptFiles: map[string]string{"749": write("cs.txt")}},
+			map[string]string{"748": "project.txt", "749": "cs.txt"}},
+		{"prompt_files over PROMPT_DIR", Project{PromptFiles: map[string]string{"748": write("pl.txt")}},
+			map[string]string{"748": "pl.txt", "750": "prompts/750.txt"}},
+		{"project languages", Project{TargetLangIDs: []string{"749", "751"}, PromptFile: write("project.txt")},
+			map[string]string{"749": "project.txt", "751": "project.txt"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config, err := tt.project.apply(base)
+			if err != nil {
+				t.Fatalf("apply: %v", err)
+			}
+			if len(config.LangPrompts) != len(tt.want) {
+				t.Fatalf("LangPrompts = %v, want %v", config.LangPrompts, tt.want)
+			}
+			for langID, prompt := range tt.want {
+				if config.LangPrompts[langID] != prompt {
+					t.Errorf("LangPrompts = %v, want %v", config.LangPrompts, tt.want)
+					break
+				}
+			}
+		})
+	}
+	if len(base.LangPrompts) != 2 {
+		t.Errorf("apply changed the shared prompts: %v", base.LangPrompts)
+	}
+}
+
+func TestReadManifest(t *testing.T) {
+	tests := []struct {
+		name     string
+		file     string
+		body     string
+		wantURLs []string
+		wantErr  string
+	}{
+		{
+			name: "yaml sorted by priority, stable for equal",
+			file: "projects.yaml",
+			body: `projects:
+  - url: https://app.lokalise.com/project/a/
+  - url: " https://app.lokalise.com/project/b/ "
+    priority: 10
+  - url: https://app.lokalise.com/project/c/
+  - url: https://app.lokalise.com/project/d/
+    priority: -1
+  - url: https://app.lokalise.com/project/e/
+    priority: 10
+`,
+			wantURLs: []string{"b", "e", "a", "c", "d"},
+		},
+		{
+			name:     "json",
+			file:     "projects.json",
+			body:     `{"projects": [{"url": "https://app.lokalise.com/project/a/"}, {"url": "https://app.lokalise.com/project/b/", "priority": 5, "target_lang_ids": ["748", "749"]}]}`,
+			wantURLs: []string{"b", "a"},
+		},
+		{"empty", "projects.yaml", "projects: []\n", nil, ""},
+		{"project without url", "projects.yaml", "projects:\n  - model: gemini-2.5-pro\n", nil, "project #1 has no url"},
+		{"broken json", "projects.json", `{"projects": [`, nil, "invalid manifest"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), tt.file)
+			os.WriteFile(path, []byte(tt.body), 0644)
+
+			projects, err := readManifest(path)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("err = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("readManifest: %v", err)
+			}
+			var urls []string
+			for _, p := range projects {
+				urls = append(urls, strings.TrimSuffix(strings.TrimPrefix(p.URL, "https://app.lokalise.com/project/"), "/"))
+			}
+			if !slices.Equal(urls, tt.wantURLs) {
+				t.Errorf("urls = %v, want %v", urls, tt.wantURLs)
+			}
+		})
+	}
+}
+
+func TestApplyOverrides(t *testing.T) {
+	ms := func(v int) *int { return &v }
+	base := Config{
+		TargetLangIDs:   []string{"748"},
+		Translator:      "gemini",
+		Model:           "gemini-2.5-flash",
+		GlossaryID:      "shared",
+		ScrollDelay:     3 * time.Second,
+		EditorLoadDelay: 2 * time.Second,
+		FocusDelay:      500 * time.Millisecond,
+		BeforeSaveDelay: time.Second,
+		RowNextDelay:    1500 * time.Millisecond,
+	}
+
+	config, err := Project{}.apply(base)
+	if err != nil || !reflect.DeepEqual(config, base) {
+		t.Errorf("empty project changed the config: %+v, %v", config, err)
+	}
+
+	project := Project{
+		TargetLangIDs: []string{"749"},
+		Translator:    "deepl",
+		Model:         "gemini-2.5-pro",
+		Glossary:      "project",
+		Delays:        &ProjectDelays{ScrollMs: ms(5000), FocusMs: ms(0), RowNextMs: ms(250)},
+	}
+	config, err = project.apply(base)
+	if err != nil {
+		t.Fatalf("apply: %v", err)
+	}
+	if !slices.Equal(config.TargetLangIDs, []string{"749"}) || config.Translator != "deepl" ||
+		config.Model != "gemini-2.5-pro" || config.GlossaryID != "project" {
+		t.Errorf("overrides = %+v", config)
+	}
+	delays := []struct {
+		name      string
+		got, want time.Duration
+	}{
+		{"scroll", config.ScrollDelay, 5 * time.Second},
+		{"editor load (not set)", config.EditorLoadDelay, 2 * time.Second},
+		{"focus (zero is a value)", config.FocusDelay, 0},
+		{"before save (not set)", config.BeforeSaveDelay, time.Second},
+		{"row next", config.RowNextDelay, 250 * time.Millisecond},
+	}
+	for _, d := range delays {
+		if d.got != d.want {
+			t.Errorf("%s delay = %v, want %v", d.name, d.got, d.want)
+		}
+	}
+	if base.ScrollDelay != 3*time.Second || base.Translator != "gemini" {
+		t.Error("apply changed the shared config")
+	}
+
+	if _, err := (Project{PromptFile: filepath.Join(t.TempDir(), "missing.txt")}).apply(base); err == nil {
+		t.Error("want an error for a missing prompt file")
+	}
+}
+
+func TestRemoveFromManifestYAML(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "projects.yaml")
+	os.WriteFile(path, []byte(`# Проекты на эту неделю
+projects:
+  - url: https://app.lokalise.com/project/a/
+    priority: 10  # срочно
+  - url: https://app.lokalise.com/project/b/
+    model: gemini-2.5-pro
+`), 0644)
+
+	if err := removeFromManifest(path, "https://app.lokalise.com/project/a/"); err != nil {
+		t.Fatalf("removeFromManifest: %v", err)
+	}
+	data, _ := os.ReadFile(path)
+	text := string(data)
+	if strings.Contains(text, "project/a/") || !strings.Contains(text, "project/b/") || !strings.Contains(text, "model: gemini-2.5-pro") {
+		t.Errorf("manifest after removal:\n%s", text)
+	}
+	if !strings.Contains(text, "# Проекты на эту неделю") {
+		t.Errorf("comment was lost:\n%s", text)
+	}
+}
+
+func TestRemoveFromManifestJSONKeepsUnknownFields(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "projects.json")
+	os.WriteFile(path, []byte(`{
+  "owner": "loc-team",
+  "projects": [
+    {"url": "https://app.lokalise.com/project/a/", "priority": 10},
+    {"url": "https://app.lokalise.com/project/b/?view=multi&lang=pl", "client": "Shop", "delays": {"scroll_ms": 3000, "note": "slow"}}
+  ]
+}
+`), 0644)
+
+	if err := removeFromManifest(path, "https://app.lokalise.com/project/a/"); err != nil {
+		t.Fatalf("removeFromManifest: %v", err)
+	}
+	data, _ := os.ReadFile(path)
+	var got map[string]any
+	if err := json.Unmarshal(data, &got); err != nil {
+		t.Fatalf("manifest is not valid json: %v\n%s", err, data)
+	}
+	want := map[string]any{
+		"owner": "loc-team",
+		"projects": []any{map[string]any{
+			"url":    "https://app.lokalise.com/project/b/?view=multi&lang=pl",
+			"client": "Shop",
+			"delays": map[string]any{"scroll_ms": float64(3000), "note": "slow"},
+		}},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("manifest after removal = %v, want %v", got, want)
+	}
+	if strings.Contains(string(data), `\u0026`) {
+		t.Errorf("url was escaped:\n%s", data)
+	}
+
+	// Оставшийся проект по-прежнему читается
+	projects, err := readManifest(path)
+	if err != nil || len(projects) != 1 || projects[0].Delays == nil || *projects[0].Delays.ScrollMs != 3000 {
+		t.Errorf("readManifest after removal = %+v, %v", projects, err)
+	}
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+)
+
+// Структуры для локального Ollama /api/chat
+type OllamaPayload struct {
+	Model    string          `json:"model"`
+	Messages []OpenAIMessage `json:"messages"`
+	Format   string          `json:"format"`
+	Stream   bool            `json:"stream"`
+}
+
+type OllamaResponse struct {
+	Message OpenAIMessage `json:"message"`
+	Done    bool          `json:"done"`
+	Error   string        `json:"error,omitempty"`
+}
+
+// ollamaTranslator — реализация Translator на локальной модели Ollama.
+// Тексты не покидают локальную сеть, ключ Gemini не нужен.
+type ollamaTranslator struct {
+	config Config
+}
+
+func (t *ollamaTranslator) Translate(ctx context.Context, items []TranslationItem) ([]TranslationItem, error) {
+	return translateWithOllama(ctx, items, t.config)
+}
+
+func translateWithOllama(ctx context.Context, items []TranslationItem, config Config) ([]TranslationItem, error) {
+	slog.InfoContext(ctx, "⏳ Запрос к Ollama...", "url", config.OllamaURL, "model", config.Model)
+
+	payload := OllamaPayload{
+		Model: config.Model,
+		Messages: []OpenAIMessage{
+			{Role: "user", Content: buildPrompt(items, config)},
+		},
+		Format: "json",
+		Stream: false,
+	}
+	jsonPayload, _ := json.Marshal(payload)
+	url := strings.TrimRight(config.OllamaURL, "/") + "/api/chat"
+
+	body, err := doWithRetry(ctx, config, func() (*http.Request, error) {
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(jsonPayload))
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("Content-Type", "application/json")
+		return req, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var chatResp OllamaResponse
+	if err := json.Unmarshal(body, &chatResp); err != nil {
+		return nil, fmt.Errorf("invalid response format: %s", string(body))
+	}
+	if chatResp.Error != "" {
+		return nil, fmt.Errorf("ollama error: %s", chatResp.Error)
+	}
+
+	return parseLLMResults(chatResp.Message.Content, "ollama")
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+)
+
+// Структуры для OpenAI-совместимого /v1/chat/completions
+// (OpenAI, Azure-шлюзы, vLLM, LM Studio и т.п.)
+type OpenAIMessage struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+type OpenAIPayload struct {
+	Model    string          `json:"model"`
+	Messages []OpenAIMessage `json:"messages"`
+}
+
+type OpenAIResponse struct {
+	Choices []struct {
+		Message      OpenAIMessage `json:"message"`
+		FinishReason string        `json:"finish_reason"`
+	} `json:"choices"`
+	Error *struct {
+		Message string `json:"message"`
+	} `json:"error,omitempty"`
+}
+
+// openAITranslator — реализация Translator для любого сервера с протоколом OpenAI chat completions.
+type openAITranslator struct {
+	config Config
+}
+
+func (t *openAITranslator) Translate(ctx context.Context, items []TranslationItem) ([]TranslationItem, error) {
+	return translateWithOpenAI(ctx, items, t.config)
+}
+
+func translateWithOpenAI(ctx context.Context, items []TranslationItem, config Config) ([]TranslationItem, error) {
+	model := config.OpenAIModel
+	if model == "" {
+		model = config.Model
+	}
+	slog.InfoContext(ctx, "⏳ Запрос к OpenAI-совместимому API...", "base_url", config.OpenAIBaseURL, "model", model)
+
+	payload := OpenAIPayload{
+		Model: model,
+		Messages: []OpenAIMessage{
+			{Role: "user", Content: buildPrompt(items, config)},
+		},
+	}
+	jsonPayload, _ := json.Marshal(payload)
+	url := strings.TrimRight(config.OpenAIBaseURL, "/") + "/chat/completions"
+
+	body, err := doWithRetry(ctx, config, func() (*http.Request, error) {
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(jsonPayload))
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("Content-Type", "application/json")
+		if config.OpenAIAPIKey != "" {
+			req.Header.Set("Authorization", "Bearer "+config.OpenAIAPIKey)
+		}
+		return req, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var chatResp OpenAIResponse
+	if err := json.Unmarshal(body, &chatResp); err != nil {
+		return nil, fmt.Errorf("invalid response format: %s", string(body))
+	}
+	if chatResp.Error != nil {
+		return nil, fmt.Errorf("api error: %s", chatResp.Error.Message)
+	}
+	if len(chatResp.Choices) == 0 {
+		return nil, fmt.Errorf("no choices in response: %s", string(body))
+	}
+
+	return parseLLMResults(chatResp.Choices[0].Message.Content, "openai")
+}
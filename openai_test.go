@@ -0,0 +1,71 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// Заглушка OpenAI-совместимого сервера: проверяет запрос и отвечает переводом,
+// обернутым в markdown, как это делают локальные модели.
+func TestTranslateWithOpenAI(t *testing.T) {
+	var gotModel, gotAuth string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/chat/completions" {
+			http.NotFound(w, r)
+			return
+		}
+		gotAuth = r.Header.Get("Authorization")
+		var payload OpenAIPayload
+		if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+			t.Errorf("decode payload: %v", err)
+		}
+		gotModel = payload.Model
+		if len(payload.Messages) != 1 || !strings.Contains(payload.Messages[0].Content, `"text":"Hello"`) {
+			t.Errorf("prompt does not contain the source text: %+v", payload.Messages)
+		}
+
+		content := "```json\n{\"results\": [{\"id\": \"1\", \"translation\": \"Cześć\"}]}\n```"
+		json.NewEncoder(w).Encode(map[string]any{
+			"choices": []map[string]any{{"message": map[string]string{"role": "assistant", "content": content}, "finish_reason": "stop"}},
+		})
+	}))
+	defer server.Close()
+
+	config := Config{
+		OpenAIBaseURL: server.URL + "/v1/",
+		OpenAIAPIKey:  "test-key",
+		Model:         "fallback-model",
+		Prompt:        "Translate to Polish.",
+	}
+	got, err := translateWithOpenAI(context.Background(), []TranslationItem{{ID: "1", Original: "Hello"}}, config)
+	if err != nil {
+		t.Fatalf("translateWithOpenAI: %v", err)
+	}
+	if len(got) != 1 || got[0].ID != "1" || got[0].Translation != "Cześć" {
+		t.Errorf("got %+v", got)
+	}
+	if gotModel != "fallback-model" {
+		t.Errorf("model = %q, want MODEL when OPENAI_MODEL is empty", gotModel)
+	}
+	if gotAuth != "Bearer test-key" {
+		t.Errorf("Authorization = %q", gotAuth)
+	}
+}
+
+func TestTranslateWithOpenAIError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte(`{"error": {"message": "model not found"}}`))
+	}))
+	defer server.Close()
+
+	config := Config{OpenAIBaseURL: server.URL, OpenAIModel: "missing"}
+	_, err := translateWithOpenAI(context.Background(), []TranslationItem{{ID: "1", Original: "Hello"}}, config)
+	if err == nil || !strings.Contains(err.Error(), "model not found") {
+		t.Fatalf("err = %v, want the server message", err)
+	}
+}
@@ -0,0 +1,281 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// placeholderPattern находит то, что переводчик обязан сохранить как есть.
+// Порядок важен: сначала плейсхолдеры Lokalise, чтобы %s внутри них не посчитался дважды.
+var placeholderPattern = regexp.MustCompile(strings.Join([]string{
+	`\[%[0-9]*\$?[a-zA-Z]*:[^\]]+\]`,                // Lokalise: [%s:name], [%1$s:name]
+	`%[0-9]+\$[-+0#]*[0-9]*(?:\.[0-9]+)?[a-zA-Z@]`,  // позиционные printf: %1$d, %2$s
+	`%[-+0#]*[0-9]*(?:\.[0-9]+)?[sdifuxXoeEgGcpb@]`, // printf: %s, %d, %.2f
+	`\{\{?[^{}\s]+\}\}?`,                            // {name}, {0}, {{name}}
+	`</?[a-zA-Z][a-zA-Z0-9]*(?:\s[^<>]*)?/?>`,       // HTML-теги: <b>, </b>, <br/>
+	`\\n`, // литеральный \n
+}, "|"))
+
+var tagAttributes = regexp.MustCompile(`^(</?[a-zA-Z][a-zA-Z0-9]*)(?:\s[^<>]*)?(/?>)$`)
+
+// extractPlaceholders возвращает плейсхолдеры и теги строки. Атрибуты тегов
+// отбрасываются: переводчик может законно перевести title или alt.
+func extractPlaceholders(text string) []string {
+	tokens := placeholderPattern.FindAllString(text, -1)
+	for i, token := range tokens {
+		if strings.HasPrefix(token, "<") {
+			tokens[i] = tagAttributes.ReplaceAllString(token, "$1$2")
+		}
+	}
+	return tokens
+}
+
+// diffPlaceholders сравнивает мультимножества плейсхолдеров оригинала и перевода.
+func diffPlaceholders(original, translation string) (missing, extra []string) {
+	counts := make(map[string]int)
+	for _, token := range extractPlaceholders(original) {
+		counts[token]++
+	}
+	for _, token := range extractPlaceholders(translation) {
+		counts[token]--
+	}
+	for token, n := range counts {
+		for ; n > 0; n-- {
+			missing = append(missing, token)
+		}
+		for ; n < 0; n++ {
+			extra = append(extra, token)
+		}
+	}
+	sort.Strings(missing)
+	sort.Strings(extra)
+	return missing, extra
+}
+
+// qaCheck — одна проверка качества перевода. Возвращает причины, по которым строку нельзя вставлять.
+type qaCheck func(item TranslationItem, config Config) []string
+
+var qaChecks = []qaCheck{
+	checkPlaceholders,
+	checkRepetition,
+	checkLengthRatio,
+}
+
+func checkPlaceholders(item TranslationItem, config Config) []string {
+	missing, extra := diffPlaceholders(item.Original, item.Translation)
+	var flags []string
+	if len(missing) > 0 {
+		flags = append(flags, "missing placeholders: "+strings.Join(missing, " "))
+	}
+	if len(extra) > 0 {
+		flags = append(flags, "extra placeholders: "+strings.Join(extra, " "))
+	}
+	return flags
+}
+
+// Порог похожести частей строки, начиная с которого считаем ее повтором самой себя
+const repetitionSimilarity = 0.9
+
+// Короче этого оригинала соотношение длин не проверяем — слишком шумно
+const lengthRatioMinRunes = 20
+
+// Части повтора короче этого не сравниваем: «Tak, tak» бывает и настоящим переводом
+const repetitionMinRunes = 5
+
+// checkRepetition ловит баг «перевод повторен дважды»: текст состоит
+// из двух-трех почти одинаковых частей, хотя оригинал таким не был.
+func checkRepetition(item TranslationItem, config Config) []string {
+	if isRepetition(item.Translation) && !isRepetition(item.Original) {
+		return []string{"translation repeats itself"}
+	}
+	return nil
+}
+
+// isRepetition проверяет, состоит ли текст из двух-трех почти одинаковых частей.
+// Знаки препинания и разделители между копиями (« - », « | », « – ») не учитываются.
+func isRepetition(text string) bool {
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+	})
+	for parts := 2; parts <= 3 && parts <= len(words); parts++ {
+		if repeatsByWords(words, parts) {
+			return true
+		}
+	}
+
+	// Посимвольно — для текстов без пробелов между словами
+	runes := []rune(strings.Join(words, " "))
+	for parts := 2; parts <= 3; parts++ {
+		// Округляем вверх: разделитель между копиями уходит в конец части,
+		// а не сдвигает следующие части на символ
+		size := (len(runes) + parts - 1) / parts
+		if size < repetitionMinRunes {
+			break
+		}
+		var chunks [][]rune
+		for i := 0; i < parts; i++ {
+			chunks = append(chunks, runes[i*size:min((i+1)*size, len(runes))])
+		}
+		if similarParts(chunks) {
+			return true
+		}
+	}
+	return false
+}
+
+// repeatsByWords делит слова на parts частей по границам слов рядом с равными
+// долями (±1 слово: копии могут немного отличаться) и сравнивает части.
+func repeatsByWords(words []string, parts int) bool {
+	var try func(bounds []int) bool
+	try = func(bounds []int) bool {
+		if len(bounds) == parts-1 {
+			edges := append(append([]int{0}, bounds...), len(words))
+			chunks := make([][]rune, parts)
+			for i := range chunks {
+				chunks[i] = []rune(strings.Join(words[edges[i]:edges[i+1]], " "))
+			}
+			return similarParts(chunks)
+		}
+		prev := 0
+		if len(bounds) > 0 {
+			prev = bounds[len(bounds)-1]
+		}
+		center := (len(words)*(len(bounds)+1) + parts/2) / parts
+		for b := center - 1; b <= center+1; b++ {
+			if b > prev && b < len(words) && try(append(bounds, b)) {
+				return true
+			}
+		}
+		return false
+	}
+	return try(nil)
+}
+
+// similarParts — все части похожи на первую, и она не слишком короткая.
+func similarParts(chunks [][]rune) bool {
+	if len(chunks[0]) < repetitionMinRunes {
+		return false
+	}
+	for _, chunk := range chunks[1:] {
+		if similarity(chunks[0], chunk) < repetitionSimilarity {
+			return false
+		}
+	}
+	return true
+}
+
+// similarity — 1 минус нормированное расстояние Левенштейна.
+func similarity(a, b []rune) float64 {
+	longest := max(len(a), len(b))
+	if longest == 0 {
+		return 1
+	}
+	return 1 - float64(levenshtein(a, b))/float64(longest)
+}
+
+func levenshtein(a, b []rune) int {
+	prev := make([]int, len(b)+1)
+	curr := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		curr[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+	return prev[len(b)]
+}
+
+// checkLengthRatio помечает переводы, длина которых подозрительно отличается от оригинала.
+func checkLengthRatio(item TranslationItem, config Config) []string {
+	originalLen := utf8.RuneCountInString(strings.TrimSpace(item.Original))
+	if originalLen < lengthRatioMinRunes {
+		return nil
+	}
+	ratio := float64(utf8.RuneCountInString(strings.TrimSpace(item.Translation))) / float64(originalLen)
+	if ratio < config.MinLengthRatio || ratio > config.MaxLengthRatio {
+		return []string{fmt.Sprintf("length ratio %.2f outside %.2f-%.2f", ratio, config.MinLengthRatio, config.MaxLengthRatio)}
+	}
+	return nil
+}
+
+// runQA прогоняет все проверки и делит строки на годные и помеченные (с заполненным Flags).
+func runQA(items []TranslationItem, config Config) (passed, flagged []TranslationItem) {
+	for _, item := range items {
+		item.Flags = nil
+		for _, check := range qaChecks {
+			item.Flags = append(item.Flags, check(item, config)...)
+		}
+		if len(item.Flags) > 0 {
+			flagged = append(flagged, item)
+		} else {
+			passed = append(passed, item)
+		}
+	}
+	return passed, flagged
+}
+
+// qaPass проверяет переводы перед вставкой и переводит помеченные строки заново
+// до config.QARetries раз. Строки, не прошедшие проверку, в редактор не попадают.
+func qaPass(ctx context.Context, translator Translator, items []TranslationItem, config Config) (passed, rejected []TranslationItem) {
+	passed, flagged := runQA(items, config)
+
+	for attempt := 1; attempt <= config.QARetries && len(flagged) > 0; attempt++ {
+		for _, item := range flagged {
+			slog.WarnContext(ctx, "🧪 QA: перевод отклонен", "id", item.ID, "flags", strings.Join(item.Flags, "; "))
+		}
+		slog.WarnContext(ctx, "🔁 QA: повторный перевод", "attempt", attempt, "count", len(flagged))
+
+		var retry []TranslationItem
+		for _, item := range flagged {
+			retry = append(retry, TranslationItem{ID: item.ID, LangID: item.LangID, Original: item.Original})
+		}
+		retranslated, _, err := translateAndReconcile(ctx, translator, retry, config)
+		if err != nil {
+			slog.WarnContext(ctx, "⚠️ QA: ошибка повторного перевода", "error", err)
+			break
+		}
+
+		ok, stillFlagged := runQA(retranslated, config)
+		passed = append(passed, ok...)
+
+		// Строки, для которых повтор ничего не вернул, остаются с прежними флагами
+		fixed := make(map[string]bool, len(retranslated))
+		for _, item := range retranslated {
+			fixed[item.ID] = true
+		}
+		for _, item := range flagged {
+			if !fixed[item.ID] {
+				stillFlagged = append(stillFlagged, item)
+			}
+		}
+		flagged = stillFlagged
+	}
+
+	for _, item := range flagged {
+		slog.ErrorContext(ctx, "🧪 QA: строка не будет вставлена", "id", item.ID, "flags", strings.Join(item.Flags, "; "))
+	}
+	return passed, flagged
+}
+
+// rejectedIDs — ключи (язык:ID) строк, отклоненных QA, с причинами для отчета.
+func rejectedIDs(items []TranslationItem) []string {
+	ids := make([]string, 0, len(items))
+	for _, item := range items {
+		ids = append(ids, fmt.Sprintf("%s (%s)", item.key(), strings.Join(item.Flags, "; ")))
+	}
+	return ids
+}
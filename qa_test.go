@@ -0,0 +1,159 @@
+package main
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestExtractPlaceholders(t *testing.T) {
+	tests := []struct {
+		text string
+		want []string
+	}{
+		{"Plain text", nil},
+		{"Hello [%s:name], you have [%1$d:count] items", []string{"[%s:name]", "[%1$d:count]"}},
+		{"%1$s of %2$s", []string{"%1$s", "%2$s"}},
+		{"Total: %.2f %s (%d%%)", []string{"%.2f", "%s", "%d"}},
+		{"Hi {name}, {0} and {{count}}", []string{"{name}", "{0}", "{{count}}"}},
+		{"Click <a href=\"/x\" title=\"Open\">here</a><br/>", []string{"<a>", "</a>", "<br/>"}},
+		{`Line one\nLine two`, []string{`\n`}},
+		{"Not a tag: 2 < 3 and { spaced }", nil},
+	}
+	for _, tt := range tests {
+		if got := extractPlaceholders(tt.text); !slices.Equal(got, tt.want) {
+			t.Errorf("extractPlaceholders(%q) = %q, want %q", tt.text, got, tt.want)
+		}
+	}
+}
+
+func TestDiffPlaceholders(t *testing.T) {
+	tests := []struct {
+		name        string
+		original    string
+		translation string
+		missing     []string
+		extra       []string
+	}{
+		{"same", "Hello [%s:name]", "Cześć [%s:name]", nil, nil},
+		{"reordered", "%1$s of %2$s", "%2$s z %1$s", nil, nil},
+		{"missing", "Hello {name}, <b>welcome</b>", "Cześć, <b>witaj", []string{"</b>", "{name}"}, nil},
+		{"extra", "Hello", "Cześć %s", nil, []string{"%s"}},
+		{"translated attribute", `<img alt="Cat">`, `<img alt="Kot">`, nil, nil},
+		{"duplicated", "{n} items", "{n} {n} elementów", nil, []string{"{n}"}},
+		{"lost newline", `One\nTwo`, "Jeden Dwa", []string{`\n`}, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			missing, extra := diffPlaceholders(tt.original, tt.translation)
+			if !slices.Equal(missing, tt.missing) || !slices.Equal(extra, tt.extra) {
+				t.Errorf("diffPlaceholders = missing %q, extra %q; want %q, %q", missing, extra, tt.missing, tt.extra)
+			}
+		})
+	}
+}
+
+func TestIsRepetition(t *testing.T) {
+	tests := []struct {
+		text string
+		want bool
+	}{
+		{"", false},
+		{"Nie, nie", false}, // слишком коротко для сравнения
+		{"Zapisz zmiany przed wyjściem", false},
+		{"Zapisz zmiany przed wyjściem Zapisz zmiany przed wyjściem", true},
+		{"Zapisz zmiany przed wyjściem. zapisz zmiany przed wyjściem!", true},
+		{"Anuluj subskrypcję Anuluj subskrypcję Anuluj subskrypcję", true},
+		{"Your order has shipped. Your invoice is attached.", false},
+		{"Zapisz zmiany - Zapisz zmiany", true},
+		{"Save changes | Save changes", true},
+		{"Anuluj – Anuluj", true},
+		{"Anuluj / anuluj / ANULUJ", true},
+		{"Nowy plik – Nowy folder", false},
+		{"Czy na pewno chcesz usunąć ten plik?", false},
+	}
+	for _, tt := range tests {
+		if got := isRepetition(tt.text); got != tt.want {
+			t.Errorf("isRepetition(%q) = %v, want %v", tt.text, got, tt.want)
+		}
+	}
+}
+
+func TestCheckRepetitionIgnoresRepeatedOriginal(t *testing.T) {
+	item := TranslationItem{Original: "Yes, yes, yes! Yes, yes, yes!", Translation: "Tak, tak, tak! Tak, tak, tak!"}
+	if flags := checkRepetition(item, Config{}); flags != nil {
+		t.Errorf("flags = %q, want none when the original repeats too", flags)
+	}
+}
+
+func TestCheckLengthRatio(t *testing.T) {
+	config := Config{MinLengthRatio: 0.5, MaxLengthRatio: 2}
+	original := "Your changes have been saved" // 28 символов
+	tests := []struct {
+		name        string
+		original    string
+		translation string
+		flagged     bool
+	}{
+		{"normal", original, "Twoje zmiany zostały zapisane", false},
+		{"too short", original, "Zapisano", true},
+		{"too long", original, strings.Repeat("Twoje zmiany zostały zapisane ", 3), true},
+		{"short original is skipped", "OK", "W porządku, zrozumiałem", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			flags := checkLengthRatio(TranslationItem{Original: tt.original, Translation: tt.translation}, config)
+			if (len(flags) > 0) != tt.flagged {
+				t.Errorf("flags = %q, flagged %v", flags, tt.flagged)
+			}
+		})
+	}
+}
+
+func TestRunQA(t *testing.T) {
+	config := Config{MinLengthRatio: 0.3, MaxLengthRatio: 3}
+	items := []TranslationItem{
+		{ID: "1", Original: "Hello [%s:name]", Translation: "Cześć [%s:name]"},
+		{ID: "2", Original: "Hello [%s:name]", Translation: "Cześć"},
+		{ID: "3", Original: "Delete this file permanently", Translation: "Usuń ten plik na zawsze Usuń ten plik na zawsze"},
+		{ID: "4", Original: "Cancel", Translation: "Anuluj", Flags: []string{"stale flag"}},
+	}
+
+	passed, flagged := runQA(items, config)
+	if ids := translatedIDs(passed); !slices.Equal(ids, []string{"1", "4"}) {
+		t.Errorf("passed = %v, want [1 4]", ids)
+	}
+	if ids := translatedIDs(flagged); !slices.Equal(ids, []string{"2", "3"}) {
+		t.Fatalf("flagged = %v, want [2 3]", ids)
+	}
+	if !slices.Equal(flagged[0].Flags, []string{"missing placeholders: [%s:name]"}) {
+		t.Errorf("flags of 2 = %q", flagged[0].Flags)
+	}
+	if !slices.Contains(flagged[1].Flags, "translation repeats itself") {
+		t.Errorf("flags of 3 = %q", flagged[1].Flags)
+	}
+	if passed[1].Flags != nil {
+		t.Errorf("stale flags were kept: %q", passed[1].Flags)
+	}
+}
+
+func TestQAPassRetranslatesFlagged(t *testing.T) {
+	// Первый перевод строки 2 теряет плейсхолдер, повтор возвращает правильный
+	translator := translatorFunc(func(ctx context.Context, items []TranslationItem) ([]TranslationItem, error) {
+		results := make([]TranslationItem, len(items))
+		for i, item := range items {
+			results[i] = TranslationItem{ID: item.ID, Translation: "Cześć {name}"}
+		}
+		return results, nil
+	})
+	items := []TranslationItem{
+		{ID: "1", Original: "Hello {name}", Translation: "Cześć {name}"},
+		{ID: "2", Original: "Hello {name}", Translation: "Cześć"},
+	}
+
+	passed, rejected := qaPass(context.Background(), translator, items, Config{QARetries: 1, MissingRetries: 1})
+	if len(rejected) != 0 || !slices.Equal(translatedIDs(passed), []string{"1", "2"}) {
+		t.Errorf("passed = %v, rejected = %v", translatedIDs(passed), translatedIDs(rejected))
+	}
+}
@@ -0,0 +1,123 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+)
+
+// translationGap — расхождения между запрошенными строками и ответом движка.
+type translationGap struct {
+	Missing   []string // ID, которых нет в ответе (или перевод пустой)
+	Duplicate []string // ID, которые пришли несколько раз
+	Unknown   []string // ID, которых мы не запрашивали (галлюцинации)
+	Empty     []string // ID с пустым переводом
+}
+
+func (g translationGap) empty() bool {
+	return len(g.Missing) == 0 && len(g.Duplicate) == 0 && len(g.Unknown) == 0 && len(g.Empty) == 0
+}
+
+// reconcileTranslations сверяет ответ с запрошенными ID. Возвращает только
+// переводы для известных ID с непустым текстом — в порядке запроса и по одному на ID.
+func reconcileTranslations(requested, got []TranslationItem) ([]TranslationItem, translationGap) {
+	var gap translationGap
+
+	originals := make(map[string]string, len(requested))
+	for _, item := range requested {
+		originals[item.ID] = item.Original
+	}
+
+	translated := make(map[string]string, len(got))
+	seen := make(map[string]bool, len(got))
+	for _, item := range got {
+		if _, ok := originals[item.ID]; !ok {
+			gap.Unknown = append(gap.Unknown, item.ID)
+			continue
+		}
+		if seen[item.ID] {
+			gap.Duplicate = append(gap.Duplicate, item.ID)
+		}
+		seen[item.ID] = true
+
+		if strings.TrimSpace(item.Translation) == "" {
+			gap.Empty = append(gap.Empty, item.ID)
+			continue
+		}
+		// Из дублей берем первый непустой перевод
+		if _, ok := translated[item.ID]; !ok {
+			translated[item.ID] = item.Translation
+		}
+	}
+
+	var results []TranslationItem
+	for _, item := range requested {
+		translation, ok := translated[item.ID]
+		if !ok {
+			gap.Missing = append(gap.Missing, item.ID)
+			continue
+		}
+		item.Translation = translation
+		results = append(results, item)
+	}
+	return results, gap
+}
+
+// translateAndReconcile переводит строки, сверяет ответ с запрошенными ID и
+// дозапрашивает только недостающие. Возвращает все полученные переводы и
+// список ID, которые так и остались без перевода.
+func translateAndReconcile(ctx context.Context, translator Translator, items []TranslationItem, config Config) ([]TranslationItem, []string, error) {
+	var results []TranslationItem
+	pending := items
+
+	for attempt := 0; attempt <= config.MissingRetries && len(pending) > 0; attempt++ {
+		if attempt > 0 {
+			slog.WarnContext(ctx, "🔁 Дозапрос недостающих переводов", "attempt", attempt, "count", len(pending))
+		}
+
+		got, err := translator.Translate(ctx, pending)
+		if err != nil {
+			// Первый запрос обязателен, ошибки дозапросов не отменяют уже полученное
+			if attempt == 0 {
+				return nil, nil, err
+			}
+			slog.WarnContext(ctx, "⚠️ Ошибка дозапроса", "error", err)
+			break
+		}
+
+		ok, gap := reconcileTranslations(pending, got)
+		results = append(results, ok...)
+		if !gap.empty() {
+			slog.WarnContext(ctx, "⚠️ Ответ не совпадает с запросом",
+				"missing", len(gap.Missing), "duplicate", len(gap.Duplicate),
+				"unknown", len(gap.Unknown), "empty", len(gap.Empty))
+		}
+
+		missing := make(map[string]bool, len(gap.Missing))
+		for _, id := range gap.Missing {
+			missing[id] = true
+		}
+		var next []TranslationItem
+		for _, item := range pending {
+			if missing[item.ID] {
+				next = append(next, item)
+			}
+		}
+		pending = next
+	}
+
+	var gapIDs []string
+	for _, item := range pending {
+		gapIDs = append(gapIDs, item.ID)
+	}
+	if len(gapIDs) > 0 {
+		slog.WarnContext(ctx, "⚠️ Остались строки без перевода", "count", len(gapIDs), "ids", strings.Join(gapIDs, ","))
+	}
+	return results, gapIDs, nil
+}
+
+// untranslatedError — проект обработан частично: часть строк осталась без перевода или отклонена QA.
+func untranslatedError(gapIDs []string, total int) error {
+	return fmt.Errorf("%d of %d rows left untranslated or rejected by QA: %s", len(gapIDs), total, strings.Join(gapIDs, ", "))
+}
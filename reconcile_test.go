@@ -0,0 +1,127 @@
+package main
+
+import (
+	"context"
+	"slices"
+	"testing"
+)
+
+func TestReconcileTranslations(t *testing.T) {
+	requested := []TranslationItem{
+		{ID: "1", LangID: "748", Original: "One"},
+		{ID: "2", LangID: "748", Original: "Two"},
+		{ID: "3", LangID: "748", Original: "Three"},
+		{ID: "4", LangID: "748", Original: "Four"},
+	}
+
+	tests := []struct {
+		name    string
+		got     []TranslationItem
+		wantIDs []string
+		want    translationGap
+	}{
+		{
+			name:    "all translated",
+			got:     []TranslationItem{{ID: "1", Translation: "Jeden"}, {ID: "2", Translation: "Dwa"}, {ID: "3", Translation: "Trzy"}, {ID: "4", Translation: "Cztery"}},
+			wantIDs: []string{"1", "2", "3", "4"},
+		},
+		{
+			name:    "missing and unknown",
+			got:     []TranslationItem{{ID: "1", Translation: "Jeden"}, {ID: "9", Translation: "Dziewięć"}, {ID: "3", Translation: "Trzy"}},
+			wantIDs: []string{"1", "3"},
+			want:    translationGap{Missing: []string{"2", "4"}, Unknown: []string{"9"}},
+		},
+		{
+			name:    "duplicate keeps first non-empty",
+			got:     []TranslationItem{{ID: "1", Translation: " "}, {ID: "1", Translation: "Jeden"}, {ID: "1", Translation: "Raz"}, {ID: "2", Translation: "Dwa"}, {ID: "3", Translation: "Trzy"}, {ID: "4", Translation: "Cztery"}},
+			wantIDs: []string{"1", "2", "3", "4"},
+			want:    translationGap{Duplicate: []string{"1", "1"}, Empty: []string{"1"}},
+		},
+		{
+			name:    "empty translation counts as missing",
+			got:     []TranslationItem{{ID: "1", Translation: "Jeden"}, {ID: "2", Translation: ""}, {ID: "3", Translation: "Trzy"}, {ID: "4", Translation: "Cztery"}},
+			wantIDs: []string{"1", "3", "4"},
+			want:    translationGap{Missing: []string{"2"}, Empty: []string{"2"}},
+		},
+		{
+			name:    "order follows the request",
+			got:     []TranslationItem{{ID: "4", Translation: "Cztery"}, {ID: "2", Translation: "Dwa"}, {ID: "3", Translation: "Trzy"}, {ID: "1", Translation: "Jeden"}},
+			wantIDs: []string{"1", "2", "3", "4"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			results, gap := reconcileTranslations(requested, tt.got)
+			if ids := translatedIDs(results); !slices.Equal(ids, tt.wantIDs) {
+				t.Errorf("ids = %v, want %v", ids, tt.wantIDs)
+			}
+			for _, item := range results {
+				if item.Original == "" || item.LangID != "748" {
+					t.Errorf("result %+v lost fields of the requested row", item)
+				}
+			}
+			if !slices.Equal(gap.Missing, tt.want.Missing) || !slices.Equal(gap.Unknown, tt.want.Unknown) ||
+				!slices.Equal(gap.Duplicate, tt.want.Duplicate) || !slices.Equal(gap.Empty, tt.want.Empty) {
+				t.Errorf("gap = %+v, want %+v", gap, tt.want)
+			}
+			if gap.empty() != tt.want.empty() {
+				t.Errorf("gap.empty() = %v", gap.empty())
+			}
+		})
+	}
+}
+
+func TestTranslateAndReconcileRequestsOnlyMissing(t *testing.T) {
+	// Первый ответ теряет строку 2, дозапрос ее возвращает
+	next := &fakeTranslator{}
+	dropped := false
+	translator := translatorFunc(func(ctx context.Context, items []TranslationItem) ([]TranslationItem, error) {
+		got, err := next.Translate(ctx, items)
+		if !dropped {
+			dropped = true
+			got = slices.DeleteFunc(got, func(item TranslationItem) bool { return item.ID == "2" })
+		}
+		return got, err
+	})
+
+	results, gapIDs, err := translateAndReconcile(context.Background(), translator, testItems(3, "Hello"), Config{MissingRetries: 2})
+	if err != nil {
+		t.Fatalf("translateAndReconcile: %v", err)
+	}
+	if len(gapIDs) != 0 {
+		t.Errorf("gapIDs = %v, want none", gapIDs)
+	}
+	if len(results) != 3 {
+		t.Errorf("results = %+v, want 3 rows", results)
+	}
+	if want := [][]string{{"1", "2", "3"}, {"2"}}; len(next.calls) != 2 || !slices.Equal(next.calls[1], want[1]) {
+		t.Errorf("calls = %v, want %v", next.calls, want)
+	}
+}
+
+func TestTranslateAndReconcileReportsGaps(t *testing.T) {
+	translator := translatorFunc(func(ctx context.Context, items []TranslationItem) ([]TranslationItem, error) {
+		var got []TranslationItem
+		for _, item := range items {
+			if item.ID != "2" {
+				got = append(got, TranslationItem{ID: item.ID, Translation: "ok"})
+			}
+		}
+		return got, nil
+	})
+
+	results, gapIDs, err := translateAndReconcile(context.Background(), translator, testItems(3, "Hello"), Config{MissingRetries: 1})
+	if err != nil {
+		t.Fatalf("translateAndReconcile: %v", err)
+	}
+	if !slices.Equal(gapIDs, []string{"2"}) || len(results) != 2 {
+		t.Errorf("results = %d rows, gapIDs = %v, want 2 rows and gap [2]", len(results), gapIDs)
+	}
+}
+
+// translatorFunc позволяет описать Translator функцией прямо в тесте.
+type translatorFunc func(ctx context.Context, items []TranslationItem) ([]TranslationItem, error)
+
+func (f translatorFunc) Translate(ctx context.Context, items []TranslationItem) ([]TranslationItem, error) {
+	return f(ctx, items)
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+)
+
+const (
+	reportFormatJSON = "json"
+	reportFormatCSV  = "csv"
+	reportFormatXLSX = "xlsx"
+)
+
+// Колонки отчета для ревью; import ищет их по названию, порядок не важен
+var reportHeader = []string{"ID", "Lang ID", "Original", "Translation", "QA flags"}
+
+// UTF-8 BOM, чтобы Excel правильно открыл CSV с польскими буквами
+const utf8BOM = "\ufeff"
+
+var unsafeFileChars = regexp.MustCompile(`[^\p{L}\p{N}._-]+`)
+
+// reportPath — reports/YYYY-MM-DD/<имя файла>-<hash>.<ext>. Хэш URL различает проекты с одинаковым именем.
+func reportPath(config Config, projectURL, filename, ext string) string {
+	name := strings.Trim(unsafeFileChars.ReplaceAllString(filename, "_"), "_")
+	if name == "" {
+		name = "project"
+	}
+	dir := filepath.Join(config.ReportDir, time.Now().Format("2006-01-02"))
+	return filepath.Join(dir, fmt.Sprintf("%s-%s.%s", name, projectHash(projectURL), ext))
+}
+
+// reportRows — таблица отчета с заголовком: ID, язык, оригинал, перевод, причины отказа QA.
+func reportRows(items []TranslationItem) [][]string {
+	rows := [][]string{reportHeader}
+	for _, item := range items {
+		rows = append(rows, []string{item.ID, item.LangID, item.Original, item.Translation, strings.Join(item.Flags, "; ")})
+	}
+	return rows
+}
+
+// writeReport сохраняет предлагаемые переводы в JSON, CSV или XLSX и возвращает путь к файлу.
+func writeReport(config Config, projectURL, filename string, items []TranslationItem) (string, error) {
+	format := strings.ToLower(config.ReportFormat)
+	path := reportPath(config, projectURL, filename, format)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return "", err
+	}
+
+	switch format {
+	case reportFormatJSON:
+		data, err := json.MarshalIndent(items, "", "  ")
+		if err != nil {
+			return "", err
+		}
+		return path, os.WriteFile(path, data, 0644)
+	case reportFormatCSV:
+		return path, writeCSVReport(path, items)
+	case reportFormatXLSX:
+		return path, writeXLSX(path, reportRows(items))
+	default:
+		return "", fmt.Errorf("unknown report format %q", config.ReportFormat)
+	}
+}
+
+func writeCSVReport(path string, items []TranslationItem) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	if _, err := file.WriteString(utf8BOM); err != nil {
+		return err
+	}
+	w := csv.NewWriter(file)
+	if err := w.WriteAll(reportRows(items)); err != nil {
+		return err
+	}
+	return file.Close()
+}
@@ -0,0 +1,180 @@
+package main
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/playwright-community/playwright-go"
+)
+
+// readReviewFile читает проверенный лингвистом отчет (CSV, XLSX или JSON).
+// Строки с пустым переводом пропускаются — их не вставляем. Плейсхолдеры
+// проверяются заново (см. reviewQA): сломанная строка попадает в rejected.
+func readReviewFile(path string) (items, rejected []TranslationItem, err error) {
+	switch strings.ToLower(strings.TrimPrefix(filepath.Ext(path), ".")) {
+	case reportFormatJSON:
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, nil, err
+		}
+		if err := json.Unmarshal(data, &items); err != nil {
+			return nil, nil, fmt.Errorf("invalid json report: %v", err)
+		}
+	case reportFormatCSV:
+		file, err := os.Open(path)
+		if err != nil {
+			return nil, nil, err
+		}
+		defer file.Close()
+
+		r := csv.NewReader(file)
+		r.FieldsPerRecord = -1
+		rows, err := r.ReadAll()
+		if err != nil {
+			return nil, nil, fmt.Errorf("invalid csv report: %v", err)
+		}
+		if items, err = reviewItemsFromRows(rows); err != nil {
+			return nil, nil, err
+		}
+	case reportFormatXLSX:
+		rows, err := readXLSX(path)
+		if err != nil {
+			return nil, nil, fmt.Errorf("invalid xlsx report: %v", err)
+		}
+		if items, err = reviewItemsFromRows(rows); err != nil {
+			return nil, nil, err
+		}
+	default:
+		return nil, nil, fmt.Errorf("unsupported review file %q, expected .csv, .xlsx or .json", path)
+	}
+
+	var results []TranslationItem
+	for _, item := range items {
+		if strings.TrimSpace(item.ID) == "" || strings.TrimSpace(item.Translation) == "" {
+			continue
+		}
+		if item.Flags = reviewQA(item); len(item.Flags) > 0 {
+			slog.Error("🧪 QA: строка из файла ревью не будет вставлена", "id", item.ID, "flags", strings.Join(item.Flags, "; "))
+			rejected = append(rejected, item)
+			continue
+		}
+		results = append(results, item)
+	}
+	return results, rejected, nil
+}
+
+// reviewQA решает, можно ли вставлять строку из файла ревью. Повторы и длину
+// оценил лингвист, а плейсхолдеры проверяем заново: в отчет попадают и отклоненные
+// строки, и нетронутый сломанный перевод нельзя вставлять. Без колонки Original
+// проверить нечего — тогда доверяем колонке QA flags: лингвист очищает ее у исправленных строк.
+func reviewQA(item TranslationItem) []string {
+	if strings.TrimSpace(item.Original) != "" {
+		return checkPlaceholders(item, Config{})
+	}
+	return item.Flags
+}
+
+// reviewItemsFromRows находит колонки ID/Original/Translation/QA flags по заголовку.
+func reviewItemsFromRows(rows [][]string) ([]TranslationItem, error) {
+	if len(rows) == 0 {
+		return nil, fmt.Errorf("review file is empty")
+	}
+
+	columns := map[string]int{}
+	for i, name := range rows[0] {
+		name = strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, utf8BOM)))
+		columns[name] = i
+	}
+	idCol, okID := columns["id"]
+	trCol, okTr := columns["translation"]
+	if !okID || !okTr {
+		return nil, fmt.Errorf("review file must have %q and %q columns", "ID", "Translation")
+	}
+	origCol, okOrig := columns["original"]
+	langCol, okLang := columns["lang id"]
+	flagsCol, okFlags := columns["qa flags"]
+
+	cell := func(row []string, col int) string {
+		if col < len(row) {
+			return row[col]
+		}
+		return ""
+	}
+
+	var items []TranslationItem
+	for _, row := range rows[1:] {
+		item := TranslationItem{
+			ID:          strings.TrimSpace(cell(row, idCol)),
+			Translation: cell(row, trCol),
+		}
+		if okOrig {
+			item.Original = cell(row, origCol)
+		}
+		if okLang {
+			item.LangID = strings.TrimSpace(cell(row, langCol))
+		}
+		if flags := strings.TrimSpace(cell(row, flagsCol)); okFlags && flags != "" {
+			item.Flags = strings.Split(flags, "; ")
+		}
+		items = append(items, item)
+	}
+	return items, nil
+}
+
+// importReview вставляет проверенный файл в проект, минуя сбор и перевод.
+// Файл становится состоянием проекта, поэтому прерванный импорт продолжится
+// со следующей несохраненной строки при повторном запуске той же команды.
+func importReview(ctx context.Context, browser playwright.Browser, reviewPath, projectURL string, config Config) (string, error) {
+	items, rejected, err := readReviewFile(reviewPath)
+	if err != nil {
+		return "", err
+	}
+	slog.Info("📥 Импорт проверенных переводов", "file", reviewPath, "url", projectURL, "rows", len(items), "rejected", len(rejected))
+
+	// Файл без колонки языка подходит только для проекта с одним языком
+	for _, list := range [][]TranslationItem{items, rejected} {
+		for i := range list {
+			if list[i].LangID != "" {
+				continue
+			}
+			if len(config.TargetLangIDs) != 1 {
+				return "", fmt.Errorf("row %s has no lang id and project has %d target languages", list[i].ID, len(config.TargetLangIDs))
+			}
+			list[i].LangID = config.TargetLangIDs[0]
+		}
+	}
+
+	store := newStateStore(config.StateDir)
+	state, err := store.Load(projectURL)
+	if err != nil {
+		return "", fmt.Errorf("could not load job state: %v", err)
+	}
+	// Тот же файл при повторном запуске — продолжаем; иначе начинаем вставку заново
+	if state.ReviewFile != reviewPath {
+		if err := store.Delete(projectURL); err != nil {
+			return "", err
+		}
+		state = &jobState{URL: projectURL, Inserted: make(map[string]bool)}
+	}
+	state.ReviewFile = reviewPath
+	state.Collected = true
+	state.Items = items
+	state.Translated = true
+	state.Translations = items
+	// Отклоненные строки попадут в итоговую ошибку проекта, как после обычного QA
+	state.Rejected = rejected
+	state.GapIDs = nil
+	if err := store.Save(state); err != nil {
+		return "", fmt.Errorf("could not save job state: %v", err)
+	}
+
+	// Импорт — это всегда реальная вставка
+	config.DryRun = false
+	return processProject(ctx, browser, projectURL, config)
+}
@@ -0,0 +1,156 @@
+package main
+
+import (
+	"context"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestReviewItemsFromRows(t *testing.T) {
+	tests := []struct {
+		name    string
+		rows    [][]string
+		want    []TranslationItem
+		wantErr bool
+	}{
+		{"empty file", nil, nil, true},
+		{"no translation column", [][]string{{"ID", "Original"}}, nil, true},
+		{
+			name: "columns in any order with BOM and short rows",
+			rows: [][]string{
+				{utf8BOM + "Translation", " qa flags ", "id", "Lang ID"},
+				{"Zapisz", "", " 1 ", "748"},
+				{"Anuluj", "missing placeholders: {n}; translation repeats itself", "2"},
+			},
+			want: []TranslationItem{
+				{ID: "1", LangID: "748", Translation: "Zapisz"},
+				{ID: "2", Translation: "Anuluj", Flags: []string{"missing placeholders: {n}", "translation repeats itself"}},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := reviewItemsFromRows(tt.rows)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, wantErr %v", err, tt.wantErr)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("got %+v, want %+v", got, tt.want)
+			}
+			for i := range got {
+				if got[i].ID != tt.want[i].ID || got[i].LangID != tt.want[i].LangID ||
+					got[i].Translation != tt.want[i].Translation || !slices.Equal(got[i].Flags, tt.want[i].Flags) {
+					t.Errorf("row %d = %+v, want %+v", i, got[i], tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+// Отчет, сохраненный экспортом и поправленный лингвистом, читается обратно
+// в любом формате: исправленные строки вставляются, сломанные — нет.
+func TestReviewRoundTrip(t *testing.T) {
+	items := []TranslationItem{
+		{ID: "1", LangID: "748", Original: "Hello [%s:name]", Translation: "Cześć [%s:name]"},
+		// Отклонен QA и исправлен лингвистом: флаги остались, но плейсхолдеры на месте
+		{ID: "2", LangID: "748", Original: "{n} files", Translation: "{n} plików", Flags: []string{"missing placeholders: {n}"}},
+		// Отклонен QA и не тронут — вставлять нельзя
+		{ID: "3", LangID: "749", Original: "<b>Save</b>", Translation: "Speichern", Flags: []string{"missing placeholders: </b> <b>"}},
+		// Перевод стерт лингвистом — строку пропускаем
+		{ID: "4", LangID: "748", Original: "Cancel", Translation: " "},
+	}
+
+	for _, format := range []string{reportFormatCSV, reportFormatXLSX, reportFormatJSON} {
+		t.Run(format, func(t *testing.T) {
+			config := Config{ReportDir: t.TempDir(), ReportFormat: format}
+			path, err := writeReport(config, "https://app.lokalise.com/project/123.abc/", "Shop", items)
+			if err != nil {
+				t.Fatalf("writeReport: %v", err)
+			}
+
+			got, rejected, err := readReviewFile(path)
+			if err != nil {
+				t.Fatalf("readReviewFile: %v", err)
+			}
+			if ids := translatedIDs(got); !slices.Equal(ids, []string{"1", "2"}) {
+				t.Errorf("items = %v, want [1 2]", ids)
+			}
+			if ids := translatedIDs(rejected); !slices.Equal(ids, []string{"3"}) {
+				t.Errorf("rejected = %v, want [3]", ids)
+			}
+			if len(got) == 2 && (got[1].LangID != "748" || got[1].Translation != "{n} plików" || got[1].Flags != nil) {
+				t.Errorf("item 2 = %+v", got[1])
+			}
+		})
+	}
+}
+
+// Без колонки Original плейсхолдеры не проверить — решает колонка QA flags.
+func TestReadReviewFileWithoutOriginal(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "review.csv")
+	data := "ID,Translation,QA flags\n1,Zapisz,\n2,Anuluj,missing placeholders: {n}\n"
+	if err := os.WriteFile(path, []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	got, rejected, err := readReviewFile(path)
+	if err != nil {
+		t.Fatalf("readReviewFile: %v", err)
+	}
+	if !slices.Equal(translatedIDs(got), []string{"1"}) || !slices.Equal(translatedIDs(rejected), []string{"2"}) {
+		t.Errorf("items = %v, rejected = %v; want [1] and [2]", translatedIDs(got), translatedIDs(rejected))
+	}
+}
+
+func TestReadReviewFileUnsupported(t *testing.T) {
+	if _, _, err := readReviewFile("review.txt"); err == nil {
+		t.Error("want an error for .txt")
+	}
+}
+
+// Импорт через API-редактор: строки, отклоненные QA, делают проект незавершенным,
+// даже если вставлять больше нечего.
+func TestImportReviewRejectedRows(t *testing.T) {
+	tests := []struct {
+		name     string
+		csv      string
+		wantPuts int
+	}{
+		{"all rows rejected", "ID,Lang ID,Original,Translation\n1,748,{n} files,plików\n2,748,<b>Save</b>,Zapisz\n", 0},
+		{"some rows rejected", "ID,Lang ID,Original,Translation\n1,748,{n} files,{n} plików\n2,748,<b>Save</b>,Zapisz\n", 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake := &fakeLokalise{t: t}
+			server := httptest.NewServer(fake)
+			defer server.Close()
+
+			// Диагностика сбоя пишется в logs/ текущей папки
+			dir := t.TempDir()
+			t.Chdir(dir)
+			reviewPath := filepath.Join(dir, "review.csv")
+			if err := os.WriteFile(reviewPath, []byte(tt.csv), 0644); err != nil {
+				t.Fatal(err)
+			}
+			config := Config{
+				EditorMode:     editorModeAPI,
+				LokaliseAPIURL: server.URL + "/api2",
+				LokaliseToken:  "test-token",
+				TargetLangIDs:  []string{"748"},
+				StateDir:       filepath.Join(dir, "state"),
+			}
+
+			_, err := importReview(context.Background(), nil, reviewPath, "https://app.lokalise.com/project/123.abc/", config)
+			if err == nil || !strings.Contains(err.Error(), "748:2") {
+				t.Errorf("err = %v, want the rejected row 748:2", err)
+			}
+			if len(fake.puts) != tt.wantPuts {
+				t.Errorf("bulk updates = %d, want %d", len(fake.puts), tt.wantPuts)
+			}
+		})
+	}
+}
@@ -0,0 +1,252 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/playwright-community/playwright-go"
+	"gopkg.in/yaml.v3"
+)
+
+// Версия формата профиля, которую понимает программа
+const selectorProfileVersion = 1
+
+// SelectorProfile — CSS-селекторы редактора и страницы входа. Хранится в YAML
+// (SELECTORS_FILE), чтобы после изменений в UI Lokalise менять файл, а не код.
+// В шаблонах {id} заменяется на ID строки, {lang_id} — на ID языка.
+type SelectorProfile struct {
+	Version int             `yaml:"version"`
+	Name    string          `yaml:"name"`
+	Editor  EditorSelectors `yaml:"editor"`
+	Login   LoginSelectors  `yaml:"login"`
+
+	health selectorHealth // результаты проверок на страницах за этот запуск
+}
+
+// Сколько проектов подряд без единой подошедшей страницы должны не найти таблицу,
+// чтобы считать профиль сломанным: у одного проекта таблица может быть пустой или грузиться дольше
+const selectorBrokenPages = 3
+
+// selectorHealth отличает устаревший профиль от проблем отдельного проекта.
+type selectorHealth struct {
+	mu      sync.Mutex
+	matched int // страниц, на которых нашлись все селекторы
+	broken  int // страниц, на которых не нашлась шапка или таблица
+}
+
+type EditorSelectors struct {
+	Filename     string `yaml:"filename"`      // имя файла в шапке проекта
+	Row          string `yaml:"row"`           // строка таблицы
+	RowIDAttr    string `yaml:"row_id_attr"`   // атрибут строки с ее ID
+	RowByID      string `yaml:"row_by_id"`     // шаблон: строка по {id}
+	TargetCell   string `yaml:"target_cell"`   // шаблон: ячейка перевода языка {lang_id}
+	EmptyMarker  string `yaml:"empty_marker"`  // признак пустой ячейки внутри нее
+	EmptyText    string `yaml:"empty_text"`    // текст-заглушка пустой ячейки
+	EmptyClick   string `yaml:"empty_click"`   // куда кликнуть в пустой ячейке, чтобы открыть редактор
+	SourceText   string `yaml:"source_text"`   // текст оригинала без служебной разметки
+	SourceCell   string `yaml:"source_cell"`   // ячейка оригинала (запасной вариант)
+	SaveButton   string `yaml:"save_button"`   // кнопка Save открытого редактора
+	OpenedEditor string `yaml:"opened_editor"` // поле ввода открытого редактора
+
+	// Как вводить перевод: type, insert или fill (см. insert.go). Пусто — type
+	InsertStrategy string `yaml:"insert_strategy"`
+}
+
+type LoginSelectors struct {
+	CookieAccept string `yaml:"cookie_accept"`
+	Email        string `yaml:"email"`
+	Password     string `yaml:"password"`
+	Submit       string `yaml:"submit"`
+	OTP          string `yaml:"otp"`
+}
+
+// rowByID — селектор строки с заданным ID.
+func (s EditorSelectors) rowByID(id string) string {
+	return strings.ReplaceAll(s.RowByID, "{id}", id)
+}
+
+// targetCell — селектор ячейки перевода для языка.
+func (s EditorSelectors) targetCell(langID string) string {
+	return strings.ReplaceAll(s.TargetCell, "{lang_id}", langID)
+}
+
+// loadSelectorProfile читает профиль и проверяет, что заданы все селекторы.
+func loadSelectorProfile(path string) (*SelectorProfile, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var profile SelectorProfile
+	if err := yaml.Unmarshal(data, &profile); err != nil {
+		return nil, fmt.Errorf("invalid selector profile %s: %v", path, err)
+	}
+	if profile.Version != selectorProfileVersion {
+		return nil, fmt.Errorf("selector profile %s has version %d, supported version is %d", path, profile.Version, selectorProfileVersion)
+	}
+
+	var missing []string
+	for _, field := range profile.fields() {
+		if strings.TrimSpace(field.value) == "" {
+			missing = append(missing, field.name)
+		}
+	}
+	if len(missing) > 0 {
+		return nil, fmt.Errorf("selector profile %s: missing %s", path, strings.Join(missing, ", "))
+	}
+	if !strings.Contains(profile.Editor.RowByID, "{id}") {
+		return nil, fmt.Errorf("selector profile %s: editor.row_by_id must contain {id}", path)
+	}
+	if !strings.Contains(profile.Editor.TargetCell, "{lang_id}") {
+		return nil, fmt.Errorf("selector profile %s: editor.target_cell must contain {lang_id}", path)
+	}
+	if profile.Editor.InsertStrategy == "" {
+		profile.Editor.InsertStrategy = insertStrategyType
+	}
+	if !slices.Contains(insertStrategies, profile.Editor.InsertStrategy) {
+		return nil, fmt.Errorf("selector profile %s: unknown editor.insert_strategy %q, expected one of %s",
+			path, profile.Editor.InsertStrategy, strings.Join(insertStrategies, ", "))
+	}
+	return &profile, nil
+}
+
+type selectorField struct {
+	name  string
+	value string
+}
+
+func (p *SelectorProfile) fields() []selectorField {
+	e, l := p.Editor, p.Login
+	return []selectorField{
+		{"editor.filename", e.Filename},
+		{"editor.row", e.Row},
+		{"editor.row_id_attr", e.RowIDAttr},
+		{"editor.row_by_id", e.RowByID},
+		{"editor.target_cell", e.TargetCell},
+		{"editor.empty_marker", e.EmptyMarker},
+		{"editor.empty_text", e.EmptyText},
+		{"editor.empty_click", e.EmptyClick},
+		{"editor.source_text", e.SourceText},
+		{"editor.source_cell", e.SourceCell},
+		{"editor.save_button", e.SaveButton},
+		{"editor.opened_editor", e.OpenedEditor},
+		{"login.cookie_accept", l.CookieAccept},
+		{"login.email", l.Email},
+		{"login.password", l.Password},
+		{"login.submit", l.Submit},
+		{"login.otp", l.OTP},
+	}
+}
+
+// selectorMismatchError — селекторы профиля ничего не нашли на открытой странице проекта.
+// Если не нашлась только колонка языка, дело в проекте (в нем нет этого языка), иначе,
+// скорее всего, поменялся UI и профиль нужно обновить (см. profileBroken).
+type selectorMismatchError struct {
+	Profile string
+	Checks  []selectorCheck
+	WaitErr error // таблица не дождалась появления строк
+	// Профиль не подошел ни к одной странице за запуск — дальше проекты обрабатывать бессмысленно
+	ProfileBroken bool
+}
+
+// selectorCheck — сколько элементов нашел селектор на странице.
+type selectorCheck struct {
+	Name     string
+	Selector string
+	Matches  int
+}
+
+func (e *selectorMismatchError) Error() string {
+	var missing []string
+	for _, check := range e.Checks {
+		if check.Matches == 0 {
+			missing = append(missing, fmt.Sprintf("%s (%s)", check.Name, check.Selector))
+		}
+	}
+	msg := fmt.Sprintf("selector profile %q does not match the page, not found: %s", e.Profile, strings.Join(missing, ", "))
+	if e.WaitErr != nil {
+		msg += fmt.Sprintf(" (rows did not appear: %v)", e.WaitErr)
+	}
+	return msg
+}
+
+// pageLevel — не нашлось то, что есть на странице любого проекта: шапка или таблица,
+// а не только колонка языка.
+func (e *selectorMismatchError) pageLevel() bool {
+	for _, check := range e.Checks {
+		if check.Matches == 0 && !strings.HasPrefix(check.Name, "editor.target_cell") {
+			return true
+		}
+	}
+	return false
+}
+
+// checkPageSelectors проверяет на только что открытой странице проекта
+// селекторы, которые должны быть видны без действий пользователя.
+// Селекторы открытого редактора (Save, поле ввода) так проверить нельзя.
+// waitErr — ошибка ожидания строк таблицы, она попадает в отчет.
+func checkPageSelectors(page playwright.Page, profile *SelectorProfile, langIDs []string, waitErr error) error {
+	e := profile.Editor
+	checks := []selectorField{
+		{"editor.filename", e.Filename},
+		{"editor.row", e.Row},
+		{"editor.source_cell", e.SourceCell},
+	}
+	for _, langID := range langIDs {
+		checks = append(checks, selectorField{"editor.target_cell[" + langID + "]", e.targetCell(langID)})
+	}
+
+	result := &selectorMismatchError{Profile: profile.Name, WaitErr: waitErr}
+	missing := false
+	for _, check := range checks {
+		count, err := page.Locator(check.value).Count()
+		if err != nil {
+			count = 0
+		}
+		missing = missing || count == 0
+		result.Checks = append(result.Checks, selectorCheck{Name: check.name, Selector: check.value, Matches: count})
+	}
+	result.ProfileBroken = profile.health.record(!missing, missing && result.pageLevel())
+	if missing {
+		return result
+	}
+	return nil
+}
+
+// record учитывает проверку одной страницы и сообщает, похож ли профиль на сломанный:
+// он не подошел ни к одной странице за запуск, а шапку или таблицу не нашел уже
+// на selectorBrokenPages страницах.
+func (h *selectorHealth) record(ok, pageLevel bool) (profileBroken bool) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if ok {
+		h.matched++
+		return false
+	}
+	if !pageLevel || h.matched > 0 {
+		return false
+	}
+	h.broken++
+	return h.broken >= selectorBrokenPages
+}
+
+// logSelectorReport выводит в лог результат проверки каждого селектора.
+func logSelectorReport(err error) {
+	var se *selectorMismatchError
+	if !errors.As(err, &se) {
+		return
+	}
+	slog.Error("🧩 Профиль селекторов не подходит к странице", "profile", se.Profile)
+	for _, check := range se.Checks {
+		if check.Matches == 0 {
+			slog.Error("   ❌ Не найден", "name", check.Name, "selector", check.Selector)
+		} else {
+			slog.Info("   ✅ Найден", "name", check.Name, "selector", check.Selector, "matches", check.Matches)
+		}
+	}
+}
@@ -0,0 +1,101 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLoadSelectorProfile(t *testing.T) {
+	shipped, err := os.ReadFile(filepath.Join("selectors", "lokalise.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name         string
+		edit         func(string) string
+		wantErr      string
+		wantStrategy string
+	}{
+		{"shipped profile", func(s string) string { return s }, "", insertStrategyType},
+		{"strategy defaults to type", func(s string) string {
+			return strings.Replace(s, "insert_strategy: type", "", 1)
+		}, "", insertStrategyType},
+		{"insert strategy", func(s string) string {
+			return strings.Replace(s, "insert_strategy: type", "insert_strategy: insert", 1)
+		}, "", insertStrategyInsert},
+		{"unknown strategy", func(s string) string {
+			return strings.Replace(s, "insert_strategy: type", "insert_strategy: paste", 1)
+		}, `unknown editor.insert_strategy "paste"`, ""},
+		{"wrong version", func(s string) string {
+			return strings.Replace(s, "version: 1", "version: 2", 1)
+		}, "has version 2", ""},
+		{"row_by_id without placeholder", func(s string) string {
+			return strings.Replace(s, "{id}", "1", -1)
+		}, "row_by_id must contain {id}", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "profile.yaml")
+			if err := os.WriteFile(path, []byte(tt.edit(string(shipped))), 0644); err != nil {
+				t.Fatal(err)
+			}
+			profile, err := loadSelectorProfile(path)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("err = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("loadSelectorProfile: %v", err)
+			}
+			if profile.Editor.InsertStrategy != tt.wantStrategy {
+				t.Errorf("insert strategy = %q, want %q", profile.Editor.InsertStrategy, tt.wantStrategy)
+			}
+		})
+	}
+}
+
+func TestSelectorMismatchPageLevel(t *testing.T) {
+	tests := []struct {
+		name   string
+		checks []selectorCheck
+		want   bool
+	}{
+		{"only language column missing", []selectorCheck{{Name: "editor.row", Matches: 20}, {Name: "editor.target_cell[748]", Matches: 0}}, false},
+		{"table missing", []selectorCheck{{Name: "editor.row", Matches: 0}, {Name: "editor.target_cell[748]", Matches: 0}}, true},
+		{"header missing", []selectorCheck{{Name: "editor.filename", Matches: 0}, {Name: "editor.row", Matches: 20}}, true},
+	}
+	for _, tt := range tests {
+		err := &selectorMismatchError{Checks: tt.checks}
+		if got := err.pageLevel(); got != tt.want {
+			t.Errorf("%s: pageLevel() = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestSelectorHealth(t *testing.T) {
+	type page struct{ ok, pageLevel bool }
+	tests := []struct {
+		name  string
+		pages []page
+		want  []bool
+	}{
+		{"stale profile", []page{{false, true}, {false, true}, {false, true}}, []bool{false, false, true}},
+		{"project without the language", []page{{false, false}, {false, false}, {false, false}, {false, false}}, []bool{false, false, false, false}},
+		{"matched once", []page{{true, false}, {false, true}, {false, true}, {false, true}}, []bool{false, false, false, false}},
+		{"language misses do not count", []page{{false, true}, {false, false}, {false, true}, {false, true}}, []bool{false, false, false, true}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var health selectorHealth
+			for i, p := range tt.pages {
+				if got := health.record(p.ok, p.pageLevel); got != tt.want[i] {
+					t.Errorf("page %d: profileBroken = %v, want %v", i+1, got, tt.want[i])
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"sync"
+
+	"github.com/playwright-community/playwright-go"
+	"gopkg.in/telebot.v4"
+)
+
+// errSessionExpired — куки из AUTH_STATE_FILE больше не действуют: редактор
+// перебросил на страницу входа или показал форму входа вместо таблицы строк.
+var errSessionExpired = errors.New("session expired")
+
+// isSignInURL — редактор перенаправил на страницу входа.
+func isSignInURL(pageURL string) bool {
+	return strings.Contains(pageURL, "/signin") || strings.Contains(pageURL, "/login")
+}
+
+// session — общая для всех воркеров авторизация. При истечении сессии первый
+// заметивший воркер входит заново, остальные ждут и продолжают с новыми куками.
+type session struct {
+	browser playwright.Browser
+	config  Config
+	tgBot   *telebot.Bot
+
+	mu         sync.RWMutex
+	generation int // номер входа; растет после каждого обновления AUTH_STATE_FILE
+}
+
+func newSession(browser playwright.Browser, config Config, tgBot *telebot.Bot) *session {
+	return &session{browser: browser, config: config, tgBot: tgBot}
+}
+
+// current ждет окончания идущего входа и возвращает номер актуальной сессии.
+// Воркер вызывает его перед каждым запуском проекта.
+func (s *session) current() int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.generation
+}
+
+// relogin входит заново, если с сессии seen никто другой этого еще не сделал.
+// Пока идет вход, новые проекты не запускаются.
+func (s *session) relogin(seen int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.generation != seen {
+		// Другой воркер уже обновил куки
+		return nil
+	}
+	slog.Warn("🔒 Сессия истекла, выполняем вход заново", "file", s.config.AuthStateFile)
+	notifyTelegram(s.config, s.tgBot, "🔒 Сессия истекла, выполняется повторный вход. Обработка проектов приостановлена.")
+	if err := login(s.browser, s.config); err != nil {
+		return err
+	}
+	s.generation++
+	return nil
+}
+
+// process запускает обработку проекта; если сессия истекла — входит заново и
+// повторяет один раз. Проект продолжается с сохраненного состояния.
+func (s *session) process(projectURL string, run func() (string, error)) (string, error) {
+	seen := s.current()
+	filename, err := run()
+	if !errors.Is(err, errSessionExpired) {
+		return filename, err
+	}
+
+	if err := s.relogin(seen); err != nil {
+		return filename, fmt.Errorf("re-login failed: %w", err)
+	}
+	slog.Info("🔓 Сессия обновлена, продолжаем проект", "url", projectURL)
+	return run()
+}
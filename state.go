@@ -0,0 +1,208 @@
+package main
+
+import (
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// jobState — состояние обработки одного проекта, переживающее падение процесса:
+// собранные строки, оплаченный ответ движка и уже сохраненные в редакторе строки.
+type jobState struct {
+	URL          string            `json:"url"`
+	Filename     string            `json:"filename"`
+	Collected    bool              `json:"collected"`
+	Items        []TranslationItem `json:"items"`
+	Translated   bool              `json:"translated"`
+	Translations []TranslationItem `json:"translations"`
+	Rejected     []TranslationItem `json:"rejected,omitempty"`
+	GapIDs       []string          `json:"gap_ids,omitempty"`
+	ReviewFile   string            `json:"review_file,omitempty"` // файл ревью, если строки пришли из import
+	UpdatedAt    time.Time         `json:"updated_at"`
+
+	// Inserted (по ключу язык:ID) хранится отдельно — в журнале, дописываемом по строке на каждое сохранение
+	Inserted map[string]bool `json:"-"`
+	// Attempted — строки, в ячейку которых уже начинали вставлять: только их можно перезаписать.
+	// Хранится в том же журнале строками с префиксом "attempt "
+	Attempted map[string]bool `json:"-"`
+}
+
+// pending возвращает переводы, которые еще не сохранены в редакторе.
+func (s *jobState) pending() []TranslationItem {
+	var items []TranslationItem
+	for _, item := range s.Translations {
+		if !s.Inserted[item.key()] {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// stateStore — локальное хранилище состояний в STATE_DIR: <hash>.json со
+// снимком проекта (пишется атомарно через rename) и <hash>.inserted с ID
+// начатых и сохраненных строк (append + fsync на каждую строку).
+type stateStore struct {
+	dir string
+}
+
+// attemptPrefix отмечает в журнале строку, которую начали вставлять.
+const attemptPrefix = "attempt "
+
+func newStateStore(dir string) *stateStore {
+	return &stateStore{dir: dir}
+}
+
+func (s *stateStore) basePath(projectURL string) string {
+	return filepath.Join(s.dir, projectHash(projectURL))
+}
+
+// projectHash — короткий стабильный идентификатор проекта для имен файлов.
+func projectHash(projectURL string) string {
+	sum := sha1.Sum([]byte(projectURL))
+	return hex.EncodeToString(sum[:8])
+}
+
+// Load читает состояние проекта. Если его нет — возвращает пустое.
+func (s *stateStore) Load(projectURL string) (*jobState, error) {
+	state := &jobState{URL: projectURL, Inserted: make(map[string]bool), Attempted: make(map[string]bool)}
+	base := s.basePath(projectURL)
+
+	data, err := os.ReadFile(base + ".json")
+	if errors.Is(err, os.ErrNotExist) {
+		return state, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, state); err != nil {
+		return nil, fmt.Errorf("corrupted state file %s: %v", base+".json", err)
+	}
+	state.Inserted = make(map[string]bool)
+	state.Attempted = make(map[string]bool)
+
+	journal, err := os.ReadFile(base + ".inserted")
+	if errors.Is(err, os.ErrNotExist) {
+		return state, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	// Ключ считается записанным только вместе с переводом строки: при падении
+	// посреди записи от "748:123" может остаться "748:1" — чужой ключ
+	lines := strings.Split(string(journal), "\n")
+	for _, line := range lines[:len(lines)-1] {
+		key := strings.TrimSpace(line)
+		if attempted, ok := strings.CutPrefix(key, attemptPrefix); ok {
+			state.Attempted[attempted] = true
+		} else if key != "" {
+			state.Inserted[key] = true
+		}
+	}
+	return state, nil
+}
+
+// Save атомарно записывает снимок состояния (без журнала вставок).
+func (s *stateStore) Save(state *jobState) error {
+	if err := os.MkdirAll(s.dir, 0755); err != nil {
+		return err
+	}
+	state.UpdatedAt = time.Now()
+	data, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	path := s.basePath(state.URL) + ".json"
+	tmp, err := os.CreateTemp(s.dir, filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// MarkInserted дописывает ключ сохраненной строки в журнал.
+func (s *stateStore) MarkInserted(state *jobState, key string) error {
+	state.Inserted[key] = true
+	return s.appendJournal(state, key)
+}
+
+// MarkAttempted дописывает в журнал строку, которую начинают вставлять, — до первого
+// клика в ячейку: после падения ее текст в ячейке считается нашим и перезаписывается.
+func (s *stateStore) MarkAttempted(state *jobState, key string) error {
+	if state.Attempted == nil {
+		state.Attempted = make(map[string]bool)
+	}
+	state.Attempted[key] = true
+	return s.appendJournal(state, attemptPrefix+key)
+}
+
+func (s *stateStore) appendJournal(state *jobState, line string) error {
+	if err := os.MkdirAll(s.dir, 0755); err != nil {
+		return err
+	}
+	file, err := os.OpenFile(s.basePath(state.URL)+".inserted", os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	if _, err := file.WriteString(line + "\n"); err != nil {
+		return err
+	}
+	return file.Sync()
+}
+
+// stateJournal связывает вставку в редакторе с журналом состояния проекта.
+type stateJournal struct {
+	ctx   context.Context
+	store *stateStore
+	state *jobState
+}
+
+func (j stateJournal) Attempted(item TranslationItem) bool {
+	return j.state.Attempted[item.key()]
+}
+
+func (j stateJournal) MarkAttempted(item TranslationItem) {
+	if err := j.store.MarkAttempted(j.state, item.key()); err != nil {
+		slog.WarnContext(j.ctx, "⚠️ Не удалось записать прогресс", "id", item.ID, "error", err)
+	}
+}
+
+func (j stateJournal) MarkSaved(item TranslationItem) {
+	if err := j.store.MarkInserted(j.state, item.key()); err != nil {
+		slog.WarnContext(j.ctx, "⚠️ Не удалось записать прогресс", "id", item.ID, "error", err)
+	}
+}
+
+// Delete удаляет состояние проекта после полной обработки.
+func (s *stateStore) Delete(projectURL string) error {
+	base := s.basePath(projectURL)
+	for _, path := range []string{base + ".json", base + ".inserted"} {
+		if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,207 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+const testProjectURL = "https://app.lokalise.com/project/123.abc/"
+
+func TestStateStoreRoundTrip(t *testing.T) {
+	store := newStateStore(filepath.Join(t.TempDir(), "state"))
+
+	state, err := store.Load(testProjectURL)
+	if err != nil {
+		t.Fatalf("Load of a new project: %v", err)
+	}
+	if state.URL != testProjectURL || state.Collected || len(state.Inserted) != 0 {
+		t.Fatalf("new state = %+v", state)
+	}
+
+	state.Filename = "Shop"
+	state.Collected = true
+	state.Items = []TranslationItem{{ID: "1", LangID: "748", Original: "Save"}, {ID: "2", LangID: "748", Original: "Cancel"}}
+	state.Translated = true
+	state.Translations = []TranslationItem{{ID: "1", LangID: "748", Original: "Save", Translation: "Zapisz"}, {ID: "2", LangID: "748", Original: "Cancel", Translation: "Anuluj"}}
+	state.GapIDs = []string{"3"}
+	if err := store.Save(state); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+	if err := store.MarkInserted(state, "748:1"); err != nil {
+		t.Fatalf("MarkInserted: %v", err)
+	}
+
+	loaded, err := store.Load(testProjectURL)
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if loaded.Filename != "Shop" || !loaded.Collected || !loaded.Translated || len(loaded.Items) != 2 ||
+		!slices.Equal(loaded.GapIDs, []string{"3"}) || loaded.UpdatedAt.IsZero() {
+		t.Errorf("loaded = %+v", loaded)
+	}
+	if ids := translatedIDs(loaded.pending()); !slices.Equal(ids, []string{"2"}) {
+		t.Errorf("pending = %v, want [2]", ids)
+	}
+
+	// Временные файлы атомарной записи не остаются в папке
+	entries, _ := os.ReadDir(store.dir)
+	if len(entries) != 2 {
+		t.Errorf("state dir has %d files, want .json and .inserted", len(entries))
+	}
+
+	if err := store.Delete(testProjectURL); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if err := store.Delete(testProjectURL); err != nil {
+		t.Errorf("second Delete: %v", err)
+	}
+	if fresh, _ := store.Load(testProjectURL); fresh.Collected || len(fresh.Inserted) != 0 {
+		t.Errorf("state after Delete = %+v", fresh)
+	}
+}
+
+func TestStateStoreJournalReplay(t *testing.T) {
+	tests := []struct {
+		name          string
+		journal       string
+		want          []string
+		wantAttempted []string
+	}{
+		{"empty", "", nil, nil},
+		{"complete lines", "748:1\n749:1\n748:2\n", []string{"748:1", "748:2", "749:1"}, nil},
+		{"duplicates and blank lines", "748:1\n\n748:1\n", []string{"748:1"}, nil},
+		{"crash in the middle of a key", "748:1\n748:12", []string{"748:1"}, nil},
+		{"truncated key that looks valid", "748:123\n748:1", []string{"748:123"}, nil},
+		{"windows line endings", "748:1\r\n748:2\r\n", []string{"748:1", "748:2"}, nil},
+		{"attempts", "attempt 748:1\n748:1\nattempt 748:2\n", []string{"748:1"}, []string{"748:1", "748:2"}},
+		{"crash in the middle of an attempt", "attempt 748:1\nattempt 748:2", nil, []string{"748:1"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := newStateStore(t.TempDir())
+			if err := store.Save(&jobState{URL: testProjectURL, Collected: true}); err != nil {
+				t.Fatal(err)
+			}
+			if err := os.WriteFile(store.basePath(testProjectURL)+".inserted", []byte(tt.journal), 0644); err != nil {
+				t.Fatal(err)
+			}
+
+			state, err := store.Load(testProjectURL)
+			if err != nil {
+				t.Fatalf("Load: %v", err)
+			}
+			if keys := sortedKeys(state.Inserted); !slices.Equal(keys, tt.want) {
+				t.Errorf("inserted = %v, want %v", keys, tt.want)
+			}
+			if keys := sortedKeys(state.Attempted); !slices.Equal(keys, tt.wantAttempted) {
+				t.Errorf("attempted = %v, want %v", keys, tt.wantAttempted)
+			}
+		})
+	}
+}
+
+func sortedKeys(m map[string]bool) []string {
+	var keys []string
+	for key := range m {
+		keys = append(keys, key)
+	}
+	slices.Sort(keys)
+	return keys
+}
+
+// Попытка вставки пишется в тот же журнал, что и сохранения, и переживает перезапуск.
+func TestStateStoreMarkAttempted(t *testing.T) {
+	store := newStateStore(t.TempDir())
+	state := &jobState{URL: testProjectURL, Collected: true, Inserted: map[string]bool{}}
+	if err := store.Save(state); err != nil {
+		t.Fatal(err)
+	}
+	for _, key := range []string{"748:1", "748:2"} {
+		if err := store.MarkAttempted(state, key); err != nil {
+			t.Fatalf("MarkAttempted: %v", err)
+		}
+	}
+	if err := store.MarkInserted(state, "748:1"); err != nil {
+		t.Fatalf("MarkInserted: %v", err)
+	}
+
+	loaded, err := store.Load(testProjectURL)
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if keys := sortedKeys(loaded.Attempted); !slices.Equal(keys, []string{"748:1", "748:2"}) {
+		t.Errorf("attempted = %v", keys)
+	}
+	if keys := sortedKeys(loaded.Inserted); !slices.Equal(keys, []string{"748:1"}) {
+		t.Errorf("inserted = %v", keys)
+	}
+	journal := stateJournal{store: store, state: loaded}
+	if !journal.Attempted(TranslationItem{ID: "2", LangID: "748"}) || journal.Attempted(TranslationItem{ID: "3", LangID: "748"}) {
+		t.Errorf("stateJournal.Attempted does not match the journal")
+	}
+}
+
+func TestStateStoreJournalWithoutSnapshot(t *testing.T) {
+	// Журнал без снимка (упали до первого Save) не воскрешает проект
+	store := newStateStore(t.TempDir())
+	os.WriteFile(store.basePath(testProjectURL)+".inserted", []byte("748:1\n"), 0644)
+
+	state, err := store.Load(testProjectURL)
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if state.Collected || len(state.Inserted) != 0 {
+		t.Errorf("state = %+v", state)
+	}
+}
+
+func TestStateStoreCorruptedSnapshot(t *testing.T) {
+	store := newStateStore(t.TempDir())
+	os.WriteFile(store.basePath(testProjectURL)+".json", []byte(`{"url": "`), 0644)
+
+	if _, err := store.Load(testProjectURL); err == nil {
+		t.Error("want an error for a corrupted snapshot")
+	}
+}
+
+func TestJobStatePending(t *testing.T) {
+	translations := []TranslationItem{
+		{ID: "1", LangID: "748"},
+		{ID: "1", LangID: "749"},
+		{ID: "2", LangID: "748"},
+	}
+	tests := []struct {
+		name     string
+		inserted []string
+		want     []string
+	}{
+		{"nothing saved", nil, []string{"748:1", "749:1", "748:2"}},
+		{"same id in another language", []string{"748:1"}, []string{"749:1", "748:2"}},
+		{"all saved", []string{"748:1", "749:1", "748:2"}, nil},
+		{"unknown keys are ignored", []string{"750:1"}, []string{"748:1", "749:1", "748:2"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			state := &jobState{Translations: translations, Inserted: map[string]bool{}}
+			for _, key := range tt.inserted {
+				state.Inserted[key] = true
+			}
+			var keys []string
+			for _, item := range state.pending() {
+				keys = append(keys, item.key())
+			}
+			if !slices.Equal(keys, tt.want) {
+				t.Errorf("pending = %v, want %v", keys, tt.want)
+			}
+		})
+	}
+}
+
+func TestProjectHash(t *testing.T) {
+	a, b := projectHash(testProjectURL), projectHash("https://app.lokalise.com/project/456.def/")
+	if len(a) != 16 || a == b || a != projectHash(testProjectURL) {
+		t.Errorf("projectHash = %q, %q", a, b)
+	}
+}
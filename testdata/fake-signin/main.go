@@ -0,0 +1,127 @@
+// Тестовая страница входа для проверки входа без браузера (HEADLESS=true).
+//
+// Запуск:
+//
+//	go run ./testdata/fake-signin
+//
+// и в другой консоли:
+//
+//	BASE_URL=http://localhost:8090 HEADLESS=true AUTH_STATE_FILE=fake-auth.json \
+//	LOKALISE_EMAIL=bot@example.com LOKALISE_PASSWORD=secret \
+//	LOKALISE_TOTP_SECRET=JBSWY3DPEHPK3PXP go run . login
+//
+// Форма двухшаговая, как у Lokalise: email, затем пароль, затем код 2FA
+// (если задан FAKE_TOTP_SECRET). После входа ставится кука и открывается /projects.
+package main
+
+import (
+	"crypto/hmac"
+	"crypto/sha1"
+	"encoding/base32"
+	"encoding/binary"
+	"fmt"
+	"html/template"
+	"log"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+var page = template.Must(template.New("signin").Parse(`<!doctype html>
+<html><body>
+<h1>Sign in</h1>
+{{if .Error}}<p class="error">{{.Error}}</p>{{end}}
+<form method="post" action="/signin">
+  <input type="hidden" name="step" value="{{.Step}}">
+  {{if eq .Step "email"}}<input type="email" name="email">{{end}}
+  {{if eq .Step "password"}}<input type="hidden" name="email" value="{{.Email}}"><input type="password" name="password">{{end}}
+  {{if eq .Step "otp"}}<input type="text" name="code" autocomplete="one-time-code">{{end}}
+  <button type="submit">Continue</button>
+</form>
+</body></html>`))
+
+type pageData struct {
+	Step  string
+	Email string
+	Error string
+}
+
+func main() {
+	addr := getEnv("FAKE_ADDR", "localhost:8090")
+	email := getEnv("FAKE_EMAIL", "bot@example.com")
+	password := getEnv("FAKE_PASSWORD", "secret")
+	totpSecret := getEnv("FAKE_TOTP_SECRET", "JBSWY3DPEHPK3PXP")
+
+	http.HandleFunc("/signin", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			page.Execute(w, pageData{Step: "email"})
+			return
+		}
+		r.ParseForm()
+		switch r.FormValue("step") {
+		case "email":
+			page.Execute(w, pageData{Step: "password", Email: r.FormValue("email")})
+		case "password":
+			if r.FormValue("email") != email || r.FormValue("password") != password {
+				page.Execute(w, pageData{Step: "email", Error: "Invalid email or password"})
+				return
+			}
+			if totpSecret == "" {
+				signIn(w, r)
+				return
+			}
+			page.Execute(w, pageData{Step: "otp"})
+		case "otp":
+			if !validCode(totpSecret, r.FormValue("code")) {
+				page.Execute(w, pageData{Step: "otp", Error: "Invalid code"})
+				return
+			}
+			signIn(w, r)
+		}
+	})
+	http.HandleFunc("/projects", func(w http.ResponseWriter, r *http.Request) {
+		if _, err := r.Cookie("session"); err != nil {
+			http.Redirect(w, r, "/signin", http.StatusFound)
+			return
+		}
+		fmt.Fprint(w, "<!doctype html><html><body><h1>Projects</h1></body></html>")
+	})
+
+	log.Printf("fake sign-in page on http://%s/signin (email=%s password=%s totp=%s)", addr, email, password, totpSecret)
+	log.Fatal(http.ListenAndServe(addr, nil))
+}
+
+func signIn(w http.ResponseWriter, r *http.Request) {
+	http.SetCookie(w, &http.Cookie{Name: "session", Value: "ok", Path: "/"})
+	http.Redirect(w, r, "/projects", http.StatusFound)
+}
+
+// validCode принимает код текущего и соседних 30-секундных окон.
+func validCode(secret, code string) bool {
+	key, err := base32.StdEncoding.WithPadding(base32.NoPadding).DecodeString(strings.ToUpper(secret))
+	if err != nil {
+		return false
+	}
+	now := time.Now().Unix() / 30
+	for step := now - 1; step <= now+1; step++ {
+		var counter [8]byte
+		binary.BigEndian.PutUint64(counter[:], uint64(step))
+		mac := hmac.New(sha1.New, key)
+		mac.Write(counter[:])
+		sum := mac.Sum(nil)
+		offset := sum[len(sum)-1] & 0x0f
+		value := binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff
+		if fmt.Sprintf("%06d", value%1000000) == code {
+			return true
+		}
+	}
+	return false
+}
+
+func getEnv(key, fallback string) string {
+	if value, exists := os.LookupEnv(key); exists {
+		return value
+	}
+	return fallback
+}
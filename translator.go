@@ -0,0 +1,212 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// GeminiResponse — формат JSON, который мы просим вернуть любую LLM.
+type GeminiResponse struct {
+	Results []TranslationItem `json:"results"`
+}
+
+// Translator — движок перевода. Получает собранные пустые строки
+// и возвращает их же с заполненным полем Translation.
+type Translator interface {
+	Translate(ctx context.Context, items []TranslationItem) ([]TranslationItem, error)
+}
+
+// newTranslator выбирает движок по config.Translator (переменная TRANSLATOR)
+// и оборачивает его в нарезку на пачки.
+func newTranslator(config Config) (Translator, error) {
+	var base Translator
+	switch strings.ToLower(strings.TrimSpace(config.Translator)) {
+	case "", "gemini":
+		base = &geminiTranslator{config: config}
+	case "openai":
+		base = &openAITranslator{config: config}
+	case "ollama":
+		base = &ollamaTranslator{config: config}
+	case "deepl":
+		base = &deeplTranslator{config: config}
+	case "mock":
+		base = &mockTranslator{}
+	default:
+		return nil, fmt.Errorf("unknown translator %q", config.Translator)
+	}
+	return newBatchingTranslator(base, config), nil
+}
+
+// mockTranslator ничего не переводит — удобно для отладки вставки без расхода квоты.
+type mockTranslator struct{}
+
+func (t *mockTranslator) Translate(ctx context.Context, items []TranslationItem) ([]TranslationItem, error) {
+	results := make([]TranslationItem, 0, len(items))
+	for _, item := range items {
+		results = append(results, TranslationItem{ID: item.ID, LangID: item.LangID, Translation: "mock translation"})
+	}
+	return results, nil
+}
+
+// groupByLang делит строки по целевым языкам, сохраняя порядок первого появления языка.
+func groupByLang(items []TranslationItem) [][]TranslationItem {
+	index := make(map[string]int)
+	var groups [][]TranslationItem
+	for _, item := range items {
+		i, ok := index[item.LangID]
+		if !ok {
+			i = len(groups)
+			index[item.LangID] = i
+			groups = append(groups, nil)
+		}
+		groups[i] = append(groups[i], item)
+	}
+	return groups
+}
+
+// readLangPrompts читает промпты для отдельных языков: <dir>/<lang_id>.txt.
+// Папки может не быть — тогда для всех языков используется prompt.txt.
+func readLangPrompts(dir string) map[string]string {
+	prompts := make(map[string]string)
+	files, _ := filepath.Glob(filepath.Join(dir, "*.txt"))
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			slog.Warn("⚠️ Не удалось прочитать промпт", "file", file, "error", err)
+			continue
+		}
+		prompts[strings.TrimSuffix(filepath.Base(file), ".txt")] = string(data)
+	}
+	return prompts
+}
+
+// forLanguage возвращает конфигурацию для перевода на один язык: свой промпт
+// и, если задано соответствие, свой код языка DeepL.
+func (c Config) forLanguage(langID string) Config {
+	c.TargetLangIDs = []string{langID}
+	if prompt, ok := c.LangPrompts[langID]; ok {
+		c.Prompt = prompt
+	}
+	c.DeepLTargetLang = langSetting(c.DeepLTargetLang, langID)
+	return c
+}
+
+// langSetting выбирает значение для языка из строки вида "748=PL,749=CS".
+// Значение без "=" действует для всех языков.
+func langSetting(value string, langID string) string {
+	if !strings.Contains(value, "=") {
+		return value
+	}
+	for _, pair := range strings.Split(value, ",") {
+		id, setting, ok := strings.Cut(strings.TrimSpace(pair), "=")
+		if ok && strings.TrimSpace(id) == langID {
+			return strings.TrimSpace(setting)
+		}
+	}
+	return ""
+}
+
+// checkLanguages не дает перевести несколько языков одной и той же настройкой:
+// prompt.txt и DEEPL_TARGET_LANG без "=" написаны под один язык, и все колонки
+// получили бы перевод на него. Для одного языка общие настройки допустимы.
+func (c Config) checkLanguages() error {
+	if len(c.TargetLangIDs) < 2 {
+		return nil
+	}
+	var missing []string
+	switch strings.ToLower(strings.TrimSpace(c.Translator)) {
+	case "mock":
+		return nil
+	case "deepl":
+		for _, langID := range c.TargetLangIDs {
+			if !strings.Contains(c.DeepLTargetLang, "=") || langSetting(c.DeepLTargetLang, langID) == "" {
+				missing = append(missing, langID)
+			}
+		}
+		if len(missing) > 0 {
+			return fmt.Errorf("%d target languages need DEEPL_TARGET_LANG as lang_id=CODE pairs, missing: %s", len(c.TargetLangIDs), strings.Join(missing, ", "))
+		}
+	default:
+		for _, langID := range c.TargetLangIDs {
+			if _, ok := c.LangPrompts[langID]; !ok {
+				missing = append(missing, langID)
+			}
+		}
+		if len(missing) > 0 {
+			return fmt.Errorf("%d target languages need a prompt per language (PROMPT_DIR/<lang_id>.txt, prompt_file or prompt_files), missing: %s", len(c.TargetLangIDs), strings.Join(missing, ", "))
+		}
+	}
+	return nil
+}
+
+// buildPrompt собирает общий для всех LLM-движков промпт: инструкции из prompt.txt
+// плюс требования к формату ответа и сами строки в JSON.
+func buildPrompt(items []TranslationItem, config Config) string {
+	// В промпт уходят только ID и оригинал, без служебных полей
+	type promptItem struct {
+		ID   string `json:"id"`
+		Text string `json:"text"`
+	}
+	promptItems := make([]promptItem, 0, len(items))
+	for _, item := range items {
+		promptItems = append(promptItems, promptItem{ID: item.ID, Text: item.Original})
+	}
+	payloadItems, _ := json.Marshal(promptItems)
+
+	// ВАШ ОРИГИНАЛЬНЫЙ ПРОМПТ
+	return fmt.Sprintf(`%s
+
+IMPORTANT: Respond ONLY with a valid JSON object. 
+Do NOT repeat the translation twice in the output string.
+Structure: {"results": [{"id": "ID_HERE", "translation": "TRANSLATED_TEXT_HERE"}, ...]}
+
+Data to translate: %s`, config.Prompt, string(payloadItems))
+}
+
+// parseLLMResults вытаскивает GeminiResponse из текстового ответа модели.
+func parseLLMResults(text string, engine string) ([]TranslationItem, error) {
+	// Применяем очистку
+	cleanJSON := sanitizeJSON(text)
+
+	var finalResp GeminiResponse
+	err := json.Unmarshal([]byte(cleanJSON), &finalResp)
+	if err != nil {
+		// Выводим текст, который не удалось распарсить, для удобства дебага
+		return nil, fmt.Errorf("Не удалось распарсить ответ от %s: %w \nТекст после очистки: %s", engine, err, cleanJSON)
+	}
+
+	return finalResp.Results, nil
+}
+
+func sanitizeJSON(input string) string {
+	// Убираем пробелы и переносы строк в начале и конце
+	input = strings.TrimSpace(input)
+
+	// Если ответ обернут в блоки кода Markdown
+	if strings.HasPrefix(input, "```") {
+		// Убираем открывающий блок (поддерживаем ```json и просто ```)
+		input = strings.TrimPrefix(input, "```json")
+		input = strings.TrimPrefix(input, "```")
+
+		// Убираем закрывающий блок
+		input = strings.TrimSuffix(input, "```")
+
+		// Повторно чистим пробелы
+		input = strings.TrimSpace(input)
+	}
+
+	// На всякий случай: если перед JSON есть какой-то текст,
+	// находим первое вхождение { и последнее }
+	start := strings.Index(input, "{")
+	end := strings.LastIndex(input, "}")
+	if start != -1 && end != -1 && end > start {
+		input = input[start : end+1]
+	}
+
+	return input
+}
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLangSetting(t *testing.T) {
+	tests := []struct {
+		value  string
+		langID string
+		want   string
+	}{
+		{"PL", "748", "PL"},
+		{"", "748", ""},
+		{"748=PL,749=CS", "748", "PL"},
+		{" 748 = PL , 749 = CS ", "749", "CS"},
+		{"748=PL,749=CS", "750", ""},
+		{"7480=DE,748=PL", "748", "PL"},
+	}
+	for _, tt := range tests {
+		if got := langSetting(tt.value, tt.langID); got != tt.want {
+			t.Errorf("langSetting(%q, %q) = %q, want %q", tt.value, tt.langID, got, tt.want)
+		}
+	}
+}
+
+func TestCheckLanguages(t *testing.T) {
+	prompts := map[string]string{"748": "Translate to Polish.", "749": "Translate to Czech."}
+	tests := []struct {
+		name    string
+		config  Config
+		wantErr string
+	}{
+		{"single language uses shared settings", Config{Translator: "gemini", TargetLangIDs: []string{"748"}}, ""},
+		{"mock ignores languages", Config{Translator: "mock", TargetLangIDs: []string{"748", "749"}}, ""},
+		{"prompt per language", Config{Translator: "gemini", TargetLangIDs: []string{"748", "749"}, LangPrompts: prompts}, ""},
+		{"missing prompt", Config{Translator: "openai", TargetLangIDs: []string{"748", "749", "750"}, LangPrompts: prompts}, "missing: 750"},
+		{"shared prompt is not enough", Config{Translator: "ollama", TargetLangIDs: []string{"748", "749"}, Prompt: "Translate to Polish."}, "missing: 748, 749"},
+		{"deepl pairs", Config{Translator: "deepl", TargetLangIDs: []string{"748", "749"}, DeepLTargetLang: "748=PL,749=CS"}, ""},
+		{"deepl single code", Config{Translator: "DeepL", TargetLangIDs: []string{"748", "749"}, DeepLTargetLang: "PL"}, "missing: 748, 749"},
+		{"deepl missing pair", Config{Translator: "deepl", TargetLangIDs: []string{"748", "749"}, DeepLTargetLang: "748=PL"}, "missing: 749"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.config.checkLanguages()
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("checkLanguages: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("err = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestForLanguage(t *testing.T) {
+	config := Config{
+		TargetLangIDs:   []string{"748", "749"},
+		Prompt:          "shared",
+		LangPrompts:     map[string]string{"749": "Translate to Czech."},
+		DeepLTargetLang: "748=PL,749=CS",
+	}
+	tests := []struct {
+		langID, prompt, deepl string
+	}{
+		{"748", "shared", "PL"},
+		{"749", "Translate to Czech.", "CS"},
+	}
+	for _, tt := range tests {
+		got := config.forLanguage(tt.langID)
+		if len(got.TargetLangIDs) != 1 || got.TargetLangIDs[0] != tt.langID || got.Prompt != tt.prompt || got.DeepLTargetLang != tt.deepl {
+			t.Errorf("forLanguage(%s) = langs %v, prompt %q, deepl %q", tt.langID, got.TargetLangIDs, got.Prompt, got.DeepLTargetLang)
+		}
+	}
+	if len(config.TargetLangIDs) != 2 {
+		t.Error("forLanguage changed the original config")
+	}
+}
+
+func TestReadLangPrompts(t *testing.T) {
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "748.txt"), []byte("Translate to Polish."), 0644)
+	os.WriteFile(filepath.Join(dir, "notes.md"), []byte("not a prompt"), 0644)
+
+	prompts := readLangPrompts(dir)
+	if len(prompts) != 1 || prompts["748"] != "Translate to Polish." {
+		t.Errorf("prompts = %v", prompts)
+	}
+	if prompts := readLangPrompts(filepath.Join(dir, "missing")); len(prompts) != 0 {
+		t.Errorf("prompts from a missing dir = %v", prompts)
+	}
+}
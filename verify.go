@@ -0,0 +1,40 @@
+package main
+
+import (
+	"strings"
+	"time"
+
+	"github.com/playwright-community/playwright-go"
+)
+
+// rowMismatch — после сохранения в ячейке оказался другой текст
+// (автозамена, потерянные нажатия, несработавший Save).
+type rowMismatch struct {
+	Item   TranslationItem
+	Actual string
+}
+
+// normalizeCellText приводит текст к виду для сравнения: редактор показывает
+// неразрывные пробелы и переносы иначе, чем они были набраны.
+func normalizeCellText(text string) string {
+	// strings.Fields считает неразрывный пробел пробелом, а вот нулевой ширины — нет
+	text = strings.ReplaceAll(text, "\u200b", "")
+	return strings.Join(strings.Fields(text), " ")
+}
+
+// verifyCell перечитывает ячейку после сохранения. Таблица обновляется не сразу,
+// поэтому текст проверяется несколько раз в течение пары секунд.
+func verifyCell(cell playwright.Locator, expected string) (actual string, ok bool) {
+	want := normalizeCellText(expected)
+	for attempt := 0; attempt < 10; attempt++ {
+		text, err := cell.InnerText()
+		if err == nil {
+			actual = text
+			if normalizeCellText(text) == want {
+				return actual, true
+			}
+		}
+		time.Sleep(200 * time.Millisecond)
+	}
+	return actual, false
+}
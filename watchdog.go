@@ -0,0 +1,91 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// timeoutError — проект или одна из его фаз не уложились в отведенное время.
+type timeoutError struct {
+	Phase   string
+	Timeout time.Duration
+}
+
+func (e *timeoutError) Error() string {
+	return fmt.Sprintf("%s timed out after %s", e.Phase, e.Timeout)
+}
+
+// withPhaseTimeout ограничивает фазу по времени; timeout <= 0 — без ограничения.
+func withPhaseTimeout(ctx context.Context, phase string, timeout time.Duration) (context.Context, context.CancelFunc) {
+	if timeout <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeoutCause(ctx, timeout, &timeoutError{Phase: phase, Timeout: timeout})
+}
+
+// timeoutCause возвращает *timeoutError, если ctx отменен по таймауту (своему или родительскому).
+func timeoutCause(ctx context.Context) error {
+	var te *timeoutError
+	if errors.As(context.Cause(ctx), &te) {
+		return te
+	}
+	return nil
+}
+
+// watchdog освобождает зависший проект: вызовы Playwright не следят за ctx,
+// поэтому по таймауту он сохраняет диагностику и закрывает редактор — все
+// ожидающие вызовы на его странице сразу завершаются ошибкой.
+type watchdog struct {
+	editor     Editor
+	projectURL string
+	logTail    *logRing
+	config     Config
+
+	diagOnce sync.Once
+	diagDir  string
+}
+
+func newWatchdog(editor Editor, projectURL string, logTail *logRing, config Config) *watchdog {
+	return &watchdog{editor: editor, projectURL: projectURL, logTail: logTail, config: config}
+}
+
+// watch следит за ctx до вызова stop. Отмена по сигналу остановки не трогает
+// редактор — текущая строка должна успеть сохраниться.
+func (w *watchdog) watch(ctx context.Context) (stop func()) {
+	done := make(chan struct{})
+	exited := make(chan struct{})
+	go func() {
+		defer close(exited)
+		select {
+		case <-done:
+		case <-ctx.Done():
+			if err := timeoutCause(ctx); err != nil {
+				w.fire(ctx, err)
+			}
+		}
+	}()
+	// stop дожидается, пока сработавший watchdog допишет диагностику
+	return func() {
+		close(done)
+		<-exited
+	}
+}
+
+func (w *watchdog) fire(ctx context.Context, cause error) {
+	slog.ErrorContext(ctx, "⏱️ Таймаут, проект прерывается", "url", w.projectURL, "error", cause)
+	w.diagnose()
+	w.editor.Close()
+}
+
+// diagnose сохраняет диагностику проекта один раз — по таймауту или по ошибке,
+// что случится раньше — и возвращает ее папку.
+func (w *watchdog) diagnose() string {
+	w.diagOnce.Do(func() {
+		w.diagDir = saveDiagnostics(w.editor, w.projectURL, w.logTail, w.config)
+	})
+	return w.diagDir
+}
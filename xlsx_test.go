@@ -0,0 +1,127 @@
+package main
+
+import (
+	"archive/zip"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestXLSXColumns(t *testing.T) {
+	tests := []struct {
+		index int
+		name  string
+	}{
+		{0, "A"},
+		{25, "Z"},
+		{26, "AA"},
+		{51, "AZ"},
+		{52, "BA"},
+		{701, "ZZ"},
+		{702, "AAA"},
+	}
+	for _, tt := range tests {
+		if got := xlsxColumnName(tt.index); got != tt.name {
+			t.Errorf("xlsxColumnName(%d) = %q, want %q", tt.index, got, tt.name)
+		}
+		if got := xlsxColumnIndex(tt.name + "12"); got != tt.index {
+			t.Errorf("xlsxColumnIndex(%q) = %d, want %d", tt.name+"12", got, tt.index)
+		}
+	}
+}
+
+func TestXLSXRoundTrip(t *testing.T) {
+	wide := make([]string, 30)
+	for i := range wide {
+		wide[i] = xlsxColumnName(i)
+	}
+	tests := []struct {
+		name string
+		rows [][]string
+	}{
+		{"report", reportRows([]TranslationItem{
+			{ID: "1", LangID: "748", Original: "Hello [%s:name]", Translation: "Cześć [%s:name]"},
+			{ID: "2", LangID: "748", Original: "Delete?", Translation: "Usunąć?", Flags: []string{"a", "b"}},
+		})},
+		{"unicode and markup", [][]string{{"Zażółć gęślą jaźń", "Привет", "日本語", "emoji 🎉"}, {"<b>bold</b> & \"quotes\"", "a < b > c"}}},
+		{"whitespace and newlines", [][]string{{"  leading", "trailing  ", "line one\nline two", "tab\there"}}},
+		{"empty cells", [][]string{{"ID", "", "Translation"}, {"", "", ""}, {"1", "", "x"}}},
+		{"many columns", [][]string{wide, wide}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "review.xlsx")
+			if err := writeXLSX(path, tt.rows); err != nil {
+				t.Fatalf("writeXLSX: %v", err)
+			}
+			got, err := readXLSX(path)
+			if err != nil {
+				t.Fatalf("readXLSX: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.rows) {
+				t.Errorf("round trip = %q, want %q", got, tt.rows)
+			}
+		})
+	}
+}
+
+// Excel и LibreOffice пересохраняют файл с общими строками, rich text
+// и пропущенными пустыми ячейками.
+func TestReadXLSXSharedStrings(t *testing.T) {
+	parts := map[string]string{
+		"[Content_Types].xml": xlsxContentTypes,
+		"_rels/.rels":         xlsxRootRels,
+		"xl/workbook.xml":     xlsxWorkbook,
+		"xl/_rels/workbook.xml.rels": `<?xml version="1.0" encoding="UTF-8"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">
+<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="/xl/worksheets/data.xml"/>
+</Relationships>`,
+		"xl/sharedStrings.xml": `<?xml version="1.0" encoding="UTF-8"?>
+<sst xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" count="3" uniqueCount="3">
+<si><t>ID</t></si>
+<si><t>Translation</t></si>
+<si><r><rPr><b/></rPr><t>Zapisz </t></r><r><t>zmiany</t></r></si>
+</sst>`,
+		"xl/worksheets/data.xml": `<?xml version="1.0" encoding="UTF-8"?>
+<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData>
+<row r="1"><c r="A1" t="s"><v>0</v></c><c r="C1" t="s"><v>1</v></c></row>
+<row r="2"><c r="A2"><v>42</v></c><c r="C2" t="s"><v>2</v></c></row>
+</sheetData></worksheet>`,
+	}
+	path := filepath.Join(t.TempDir(), "excel.xlsx")
+	file, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	zw := zip.NewWriter(file)
+	for name, body := range parts {
+		w, err := zw.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		w.Write([]byte(body))
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	file.Close()
+
+	got, err := readXLSX(path)
+	if err != nil {
+		t.Fatalf("readXLSX: %v", err)
+	}
+	want := [][]string{{"ID", "", "Translation"}, {"42", "", "Zapisz zmiany"}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("readXLSX = %q, want %q", got, want)
+	}
+}
+
+func TestReadXLSXNotAnArchive(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "broken.xlsx")
+	os.WriteFile(path, []byte("ID,Translation\n1,x\n"), 0644)
+	if _, err := readXLSX(path); err == nil || !strings.Contains(err.Error(), "zip") {
+		t.Errorf("err = %v, want a zip error", err)
+	}
+}